@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	tfthttp "github.com/rsdlab-dk/tft-core/http"
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"github.com/rsdlab-dk/tft-core/riot"
+)
+
+func main() {
+	apiKey := os.Getenv("RIOT_API_KEY")
+	if apiKey == "" {
+		log.Fatal("RIOT_API_KEY environment variable is required")
+	}
+
+	logg, err := logger.New("development")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer logg.Sync()
+
+	limiter := ratelimit.NewMemoryLimiter()
+	defer limiter.Close()
+
+	server := tfthttp.NewServer(tfthttp.ServerConfig{
+		Addr:        ":8080",
+		RiotClient:  riot.NewClient(apiKey),
+		RateLimiter: limiter,
+		Logger:      logg,
+	})
+
+	logg.Info("🚀 TFT server listening on " + server.Addr)
+	if err := server.ListenAndServe(); err != nil {
+		logg.Fatal(err.Error())
+	}
+}
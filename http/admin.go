@@ -0,0 +1,112 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"go.uber.org/zap"
+)
+
+type RateLimitReport struct {
+	Decisions    []ratelimit.DecisionCount `json:"decisions"`
+	ActiveKeys   int                       `json:"active_keys"`
+	TopConsumers []ratelimit.Consumer      `json:"top_consumers"`
+}
+
+type RateLimitKeyReport struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// RateLimitAdminHandler exposes rate limiter state for operators. GET lists
+// decision counters and top consumers, GET ?key= shows a single bucket and
+// DELETE ?key= resets it. It is not rate limited or CORS enabled, so mount it
+// on an internal listener or behind authentication.
+func RateLimitAdminHandler(limiter ratelimit.Limiter, stats *ratelimit.Stats, log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+
+		switch {
+		case r.Method == http.MethodGet && key != "":
+			count, err := limiter.GetCount(r.Context(), key)
+			if err != nil {
+				log.WithContext(r.Context()).Error("rate limit count error",
+					zap.String("key", key),
+					zap.Error(err))
+				WriteInternalError(w, log, r)
+				return
+			}
+
+			WriteJSON(w, RateLimitKeyReport{Key: key, Count: count}, log, r)
+
+		case r.Method == http.MethodGet:
+			top := 10
+			if value := r.URL.Query().Get("top"); value != "" {
+				n, err := strconv.Atoi(value)
+				if err != nil || n <= 0 {
+					WriteBadRequest(w, "Invalid top parameter", log, r)
+					return
+				}
+				top = n
+			}
+
+			report := RateLimitReport{
+				Decisions:    []ratelimit.DecisionCount{},
+				TopConsumers: []ratelimit.Consumer{},
+			}
+			if stats != nil {
+				report.Decisions = stats.Snapshot()
+			}
+
+			if introspector, ok := limiter.(ratelimit.Introspector); ok {
+				activeKeys, err := introspector.ActiveKeys(r.Context())
+				if err != nil {
+					log.WithContext(r.Context()).Error("rate limit introspection error",
+						zap.Error(err))
+					WriteInternalError(w, log, r)
+					return
+				}
+
+				consumers, err := introspector.TopConsumers(r.Context(), top)
+				if err != nil {
+					log.WithContext(r.Context()).Error("rate limit introspection error",
+						zap.Error(err))
+					WriteInternalError(w, log, r)
+					return
+				}
+
+				report.ActiveKeys = activeKeys
+				if consumers != nil {
+					report.TopConsumers = consumers
+				}
+			}
+
+			WriteJSON(w, report, log, r)
+
+		case r.Method == http.MethodDelete:
+			if key == "" {
+				WriteBadRequest(w, "Key parameter is required", log, r)
+				return
+			}
+
+			if err := limiter.Reset(r.Context(), key); err != nil {
+				log.WithContext(r.Context()).Error("rate limit reset error",
+					zap.String("key", key),
+					zap.Error(err))
+				WriteInternalError(w, log, r)
+				return
+			}
+
+			log.WithContext(r.Context()).Info("rate limit bucket reset",
+				zap.String("key", key))
+
+			WriteJSON(w, RateLimitKeyReport{Key: key}, log, r)
+
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			WriteError(w, "METHOD_NOT_ALLOWED", "Method not allowed", http.StatusMethodNotAllowed, log, r)
+		}
+	}
+}
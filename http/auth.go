@@ -0,0 +1,165 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"go.uber.org/zap"
+)
+
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// AuthRateLimitRule names the rate limit rule MountTFTRoutes applies per
+// client IP before checking credentials.
+const AuthRateLimitRule = "auth"
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID string
+	// Method is "apikey" or "jwt".
+	Method string
+	// Plan is the rate-limit plan of the principal, if its credential
+	// carries one. See PrincipalPlans.
+	Plan string
+}
+
+// Authenticator checks one kind of credential. It returns a nil principal
+// and a nil error when the request does not carry that kind at all, so the
+// next authenticator can be tried.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+type AuthConfig struct {
+	// Authenticators are tried in order; the first to find its credential
+	// decides the outcome.
+	Authenticators []Authenticator
+	// Optional lets requests without any credential through anonymously.
+	// Invalid credentials are rejected either way.
+	Optional bool
+}
+
+// APIKey is a static key as stored in configuration: only its SHA-256, in
+// hex, is kept. Use HashAPIKey to produce it.
+type APIKey struct {
+	Hash string `json:"hash" yaml:"hash"`
+	ID   string `json:"id" yaml:"id"`
+	Plan string `json:"plan,omitempty" yaml:"plan,omitempty"`
+}
+
+type apiKeyAuthenticator struct {
+	header string
+	keys   map[[sha256.Size]byte]APIKey
+}
+
+// WithAuth authenticates each request and stores the principal in its
+// context. Failures get 401 UNAUTHORIZED.
+func WithAuth(config AuthConfig, log *logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, authenticator := range config.Authenticators {
+				principal, err := authenticator.Authenticate(r)
+				if err != nil {
+					log.WithContext(r.Context()).Warn("authentication failed",
+						zap.String("path", r.URL.Path),
+						zap.Error(err))
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					WriteError(w, "UNAUTHORIZED", "Invalid credentials", http.StatusUnauthorized, log, r)
+					return
+				}
+
+				if principal != nil {
+					next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), principal)))
+					return
+				}
+			}
+
+			if !config.Optional {
+				log.WithContext(r.Context()).Warn("missing credentials",
+					zap.String("path", r.URL.Path))
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				WriteError(w, "UNAUTHORIZED", "Authentication required", http.StatusUnauthorized, log, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKeyAuthenticator checks the given header, DefaultAPIKeyHeader when
+// empty, against hashed keys.
+func NewAPIKeyAuthenticator(header string, keys []APIKey) (Authenticator, error) {
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+
+	authenticator := &apiKeyAuthenticator{
+		header: header,
+		keys:   make(map[[sha256.Size]byte]APIKey, len(keys)),
+	}
+
+	for _, key := range keys {
+		raw, err := hex.DecodeString(key.Hash)
+		if err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("api key %q: hash must be a hex sha-256", key.ID)
+		}
+		if key.ID == "" {
+			return nil, fmt.Errorf("api key %s: id is required", key.Hash)
+		}
+		authenticator.keys[[sha256.Size]byte(raw)] = key
+	}
+
+	return authenticator, nil
+}
+
+func (a *apiKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	presented := r.Header.Get(a.header)
+	if presented == "" {
+		return nil, nil
+	}
+
+	// Only digests are stored, so looking the digest up leaks nothing
+	// useful about the keys through timing.
+	sum := sha256.Sum256([]byte(presented))
+	key, ok := a.keys[sum]
+	if !ok {
+		return nil, fmt.Errorf("api key: %w", ErrInvalidCredentials)
+	}
+
+	return &Principal{ID: key.ID, Method: "apikey", Plan: key.Plan}, nil
+}
+
+// PrincipalPlans resolves the rate-limit plan from the authenticated
+// principal, falling back to another lookup (which may be nil) for
+// anonymous callers and principals without a plan.
+func PrincipalPlans(fallback ratelimit.PlanLookup) ratelimit.PlanLookup {
+	return principalPlans{fallback: fallback}
+}
+
+type principalPlans struct {
+	fallback ratelimit.PlanLookup
+}
+
+func (p principalPlans) LookupPlan(ctx context.Context, key string) (string, error) {
+	if principal := PrincipalFromContext(ctx); principal != nil && principal.Plan != "" {
+		return principal.Plan, nil
+	}
+	if p.fallback == nil {
+		return "", nil
+	}
+	return p.fallback.LookupPlan(ctx, key)
+}
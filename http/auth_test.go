@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"github.com/rsdlab-dk/tft-core/riot"
+)
+
+func TestMountTFTRoutesRateLimitsFailedAuth(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiKeys, err := NewAPIKeyAuthenticator("", []APIKey{{Hash: HashAPIKey("valid-key"), ID: "client-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := ratelimit.NewConfig()
+	rules.Rules[AuthRateLimitRule] = ratelimit.NewRule(2, time.Minute)
+
+	server := NewServer(ServerConfig{
+		RiotClient:       riot.NewClient("test-key"),
+		RateLimiter:      ratelimit.NewMemoryLimiter(ratelimit.WithCleanupInterval(0)),
+		Logger:           log,
+		RateLimitOptions: []RateLimitOption{RateLimitRules(rules)},
+		Auth:             &AuthConfig{Authenticators: []Authenticator{apiKeys}},
+	})
+
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/summoner/by-puuid?puuid=x", nil)
+		req.Header.Set(DefaultAPIKeyHeader, "guessed-key")
+		rec := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rec, req)
+
+		if rec.Code != want {
+			t.Errorf("attempt %d: status = %d, want %d", i+1, rec.Code, want)
+		}
+	}
+}
+
+func TestMountTFTRoutesKeysAuthOnForwardedClientIP(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiKeys, err := NewAPIKeyAuthenticator("", []APIKey{{Hash: HashAPIKey("valid-key"), ID: "client-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := ratelimit.NewConfig()
+	rules.Rules[AuthRateLimitRule] = ratelimit.NewRule(1, time.Minute)
+
+	server := NewServer(ServerConfig{
+		RiotClient:       riot.NewClient("test-key"),
+		RateLimiter:      ratelimit.NewMemoryLimiter(ratelimit.WithCleanupInterval(0)),
+		Logger:           log,
+		RateLimitOptions: []RateLimitOption{RateLimitRules(rules)},
+		Auth:             &AuthConfig{Authenticators: []Authenticator{apiKeys}},
+		ClientIP:         ClientIPConfig{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}},
+	})
+
+	// Both clients reach the server through the same balancer, so only the
+	// forwarded address tells them apart.
+	for _, client := range []string{"203.0.113.7", "203.0.113.8"} {
+		req := httptest.NewRequest(http.MethodGet, "/summoner/by-puuid?puuid=x", nil)
+		req.RemoteAddr = "10.0.0.5:1234"
+		req.Header.Set("X-Forwarded-For", client)
+		req.Header.Set(DefaultAPIKeyHeader, "guessed-key")
+		rec := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("client %s: status = %d, want 401 from its own bucket", client, rec.Code)
+		}
+	}
+}
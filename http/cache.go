@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const cacheControlKey contextKey = "cache_control"
+
+// WithCacheControl sets the Cache-Control directive sent with successful
+// responses written by WriteJSON, e.g. "public, max-age=300". Errors are
+// never given it.
+func WithCacheControl(directive string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), cacheControlKey, directive)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// SetLastModified records when the data behind a response last changed so
+// WriteJSON can answer If-Modified-Since. Call it before WriteJSON.
+func SetLastModified(w http.ResponseWriter, modified time.Time) {
+	if modified.IsZero() {
+		return
+	}
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+}
+
+// ETag returns a strong entity tag for payload.
+func ETag(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+func cacheControlFromContext(ctx context.Context) string {
+	directive, _ := ctx.Value(cacheControlKey).(string)
+	return directive
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when no
+// If-None-Match is sent, as RFC 9110 section 13.2.2 orders them.
+func notModified(r *http.Request, header http.Header) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, header.Get("ETag"))
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	lastModified := header.Get("Last-Modified")
+	if ims == "" || lastModified == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+
+	return !modified.After(since)
+}
+
+// etagMatches uses the weak comparison required for If-None-Match, so weak
+// and strong forms of the same tag match.
+func etagMatches(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,35 @@
+package http
+
+import "net/http"
+
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes middleware so that the first one added is the outermost,
+// i.e. NewChain(a, b).Then(h) serves requests as a(b(h)).
+type Chain struct {
+	middlewares []Middleware
+}
+
+func NewChain(middlewares ...Middleware) Chain {
+	return Chain{middlewares: append([]Middleware(nil), middlewares...)}
+}
+
+// Append returns a new chain; the receiver is left untouched so a base chain
+// can be shared between routes.
+func (c Chain) Append(middlewares ...Middleware) Chain {
+	combined := make([]Middleware, 0, len(c.middlewares)+len(middlewares))
+	combined = append(combined, c.middlewares...)
+	combined = append(combined, middlewares...)
+	return Chain{middlewares: combined}
+}
+
+func (c Chain) Then(h http.Handler) http.Handler {
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.middlewares[i](h)
+	}
+	return h
+}
+
+func (c Chain) ThenFunc(fn http.HandlerFunc) http.Handler {
+	return c.Then(fn)
+}
@@ -0,0 +1,301 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const DefaultCompressionMinSize = 1024
+
+type CompressionConfig struct {
+	// MinSize is the smallest body, in bytes, worth compressing. Zero means
+	// DefaultCompressionMinSize.
+	MinSize int
+	// Encodings lists the supported codings from most to least preferred,
+	// out of "br", "zstd" and "gzip". It breaks ties between codings the
+	// client weighs equally. Nil means all three, in that order.
+	Encodings []string
+}
+
+// encoder is implemented by the gzip, zstd and brotli writers, which can all
+// be reset onto a new destination and reused.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	"br": {New: func() any {
+		return brotli.NewWriterLevel(io.Discard, 5)
+	}},
+	"zstd": {New: func() any {
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+		return w
+	}},
+	"gzip": {New: func() any {
+		return gzip.NewWriter(io.Discard)
+	}},
+}
+
+func DefaultCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		MinSize:   DefaultCompressionMinSize,
+		Encodings: []string{"br", "zstd", "gzip"},
+	}
+}
+
+// WithCompression compresses responses with the best coding the client
+// accepts. Bodies are buffered until MinSize bytes are written, so small
+// responses go out untouched. A compressed response gets its ETag suffixed
+// with the coding ("abc" becomes "abc-gzip"), and the suffix is removed from
+// If-None-Match before the handler sees it so WriteJSON still matches.
+//
+// It panics if Encodings names a coding other than "br", "zstd" or "gzip",
+// so a bad configuration fails at startup rather than on the first response.
+func WithCompression(config CompressionConfig) Middleware {
+	if config.MinSize <= 0 {
+		config.MinSize = DefaultCompressionMinSize
+	}
+	if config.Encodings == nil {
+		config.Encodings = DefaultCompressionConfig().Encodings
+	}
+	for _, encoding := range config.Encodings {
+		if _, ok := encoderPools[encoding]; !ok {
+			panic(fmt.Sprintf("http: unsupported compression coding %q", encoding))
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), config.Encodings)
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			suffixed := false
+			if inm := r.Header.Get("If-None-Match"); inm != "" {
+				stripped := strings.ReplaceAll(inm, "-"+encoding+`"`, `"`)
+				suffixed = stripped != inm
+
+				r = r.WithContext(r.Context())
+				r.Header = r.Header.Clone()
+				r.Header.Set("If-None-Match", stripped)
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				minSize:        config.MinSize,
+				statusCode:     http.StatusOK,
+				suffixETag:     suffixed,
+			}
+
+			next.ServeHTTP(cw, r)
+			cw.Close()
+		})
+	}
+}
+
+// compressWriter holds back the status and the first MinSize bytes until it
+// knows whether the body is worth compressing, then commits either way.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	minSize     int
+	statusCode  int
+	wroteHeader bool
+	committed   bool
+	suffixETag  bool
+	buf         bytes.Buffer
+	enc         encoder
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader || cw.committed {
+		return
+	}
+
+	// 1xx responses are informational and may precede the real one.
+	if code >= 100 && code < 200 {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	cw.statusCode = code
+	cw.wroteHeader = true
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.committed {
+		if cw.enc != nil {
+			return cw.enc.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf.Write(b)
+	if cw.buf.Len() >= cw.minSize {
+		if err := cw.commit(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush commits the response as it stands: compressed if the coding applies,
+// so later writes are streamed through the encoder.
+func (cw *compressWriter) Flush() {
+	if !cw.committed {
+		cw.commit(cw.buf.Len() > 0)
+	}
+	if cw.enc != nil {
+		cw.enc.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+func (cw *compressWriter) Close() error {
+	if !cw.committed {
+		if err := cw.commit(cw.buf.Len() >= cw.minSize); err != nil {
+			return err
+		}
+	}
+
+	if cw.enc == nil {
+		return nil
+	}
+
+	err := cw.enc.Close()
+	cw.enc.Reset(io.Discard)
+	encoderPools[cw.encoding].Put(cw.enc)
+	cw.enc = nil
+	return err
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) commit(large bool) error {
+	cw.committed = true
+	header := cw.Header()
+
+	if cw.statusCode == http.StatusNotModified && cw.suffixETag {
+		setETagSuffix(header, cw.encoding)
+	}
+
+	if large && cw.compressible(header) {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		setETagSuffix(header, cw.encoding)
+
+		cw.enc = encoderPools[cw.encoding].Get().(encoder)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.statusCode)
+
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(cw.buf.Bytes())
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf.Bytes())
+	}
+	cw.buf.Reset()
+	return err
+}
+
+func (cw *compressWriter) compressible(header http.Header) bool {
+	if cw.statusCode < 200 || cw.statusCode == http.StatusNoContent || cw.statusCode == http.StatusNotModified {
+		return false
+	}
+
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/javascript"
+}
+
+func setETagSuffix(header http.Header, encoding string) {
+	etag := header.Get("ETag")
+	if !strings.HasSuffix(etag, `"`) || strings.HasSuffix(etag, "-"+encoding+`"`) {
+		return
+	}
+	header.Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+encoding+`"`)
+}
+
+// negotiateEncoding picks the supported coding with the highest quality in
+// Accept-Encoding, preferring earlier entries of supported on ties. It
+// returns "" when nothing but identity is acceptable.
+func negotiateEncoding(accept string, supported []string) string {
+	if accept == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		qualities[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range supported {
+		q, ok := qualities[coding]
+		if !ok {
+			if q, ok = qualities["*"]; !ok {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithCompressionRejectsUnknownCoding(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("want a panic for an unsupported coding")
+		}
+	}()
+
+	WithCompression(CompressionConfig{Encodings: []string{"deflate"}})
+}
+
+func TestWithCompression(t *testing.T) {
+	body := strings.Repeat(`{"tier":"CHALLENGER"}`, 100)
+	handler := WithCompression(CompressionConfig{Encodings: []string{"gzip"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "deflate, gzip;q=0.5")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != body {
+		t.Error("decompressed body differs from the original")
+	}
+}
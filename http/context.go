@@ -0,0 +1,75 @@
+package http
+
+import "context"
+
+type contextKey string
+
+const userIDKey contextKey = "user_id"
+
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+func UserIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	userID, ok := ctx.Value(userIDKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return userID
+}
+
+const (
+	responseFormatKey  contextKey = "response_format"
+	problemTypeBaseKey contextKey = "problem_type_base"
+)
+
+func ContextWithResponseFormat(ctx context.Context, format ResponseFormat) context.Context {
+	return context.WithValue(ctx, responseFormatKey, format)
+}
+
+// ResponseFormatFromContext returns the error format chosen for the request,
+// FormatEnvelope when none was set.
+func ResponseFormatFromContext(ctx context.Context) ResponseFormat {
+	if ctx == nil {
+		return FormatEnvelope
+	}
+
+	format, ok := ctx.Value(responseFormatKey).(ResponseFormat)
+	if !ok {
+		return FormatEnvelope
+	}
+
+	return format
+}
+
+func problemTypeBaseFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	base, _ := ctx.Value(problemTypeBaseKey).(string)
+	return base
+}
+
+const principalKey contextKey = "principal"
+
+// ContextWithPrincipal stores the authenticated principal and its ID as the
+// user ID, so UserIDKey rate limits by principal.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	ctx = context.WithValue(ctx, principalKey, principal)
+	return ContextWithUserID(ctx, principal.ID)
+}
+
+func PrincipalFromContext(ctx context.Context) *Principal {
+	if ctx == nil {
+		return nil
+	}
+
+	principal, _ := ctx.Value(principalKey).(*Principal)
+	return principal
+}
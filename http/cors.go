@@ -0,0 +1,230 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSConfig struct {
+	// AllowedOrigins lists exact origins ("https://app.example.com"),
+	// wildcard subdomains ("https://*.example.com", which does not match the
+	// apex) or "*" for any origin. "*" cannot be combined with
+	// AllowCredentials, which would let any site make credentialed reads.
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders lists request headers a preflight may ask for. "*"
+	// allows any header unless AllowCredentials is set.
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight. Zero sends no
+	// Access-Control-Max-Age.
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig allows any origin to read the API without credentials.
+// Only read methods are allowed, and the headers clients need for request
+// correlation, caching and retrying are exposed.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+		AllowedHeaders: []string{
+			"Accept",
+			"Accept-Encoding",
+			"Authorization",
+			"Content-Type",
+			"If-Modified-Since",
+			"If-None-Match",
+			"X-API-Key",
+			"X-Request-ID",
+			"traceparent",
+			"tracestate",
+		},
+		ExposedHeaders: []string{
+			"ETag",
+			"Retry-After",
+			"X-Request-ID",
+		},
+		MaxAge: 10 * time.Minute,
+	}
+}
+
+var defaultCORS = WithCORSPolicy(DefaultCORSConfig())
+
+// WithCORS applies DefaultCORSConfig.
+func WithCORS(next http.Handler) http.Handler {
+	return defaultCORS(next)
+}
+
+// WithCORSPolicy answers preflight requests itself and adds CORS headers to
+// actual requests from allowed origins. Requests from other origins are
+// served without CORS headers, which makes browsers withhold the response.
+// It panics when AllowedOrigins has "*" and AllowCredentials is set.
+func WithCORSPolicy(config CORSConfig) Middleware {
+	policy := newCORSPolicy(config)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if !policy.anyOrigin {
+				w.Header().Add("Vary", "Origin")
+			}
+
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				policy.preflight(w, r, origin)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if origin != "" && policy.allowOrigin(origin) {
+				policy.setOrigin(w.Header(), origin)
+				if policy.exposed != "" {
+					w.Header().Set("Access-Control-Expose-Headers", policy.exposed)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type corsPolicy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	wildcards   []wildcardOrigin
+	methods     map[string]bool
+	methodList  string
+	anyHeader   bool
+	headers     map[string]bool
+	exposed     string
+	credentials bool
+	maxAge      string
+}
+
+// wildcardOrigin matches "scheme://*.suffix" as a prefix and a suffix.
+type wildcardOrigin struct {
+	prefix string
+	suffix string
+}
+
+func newCORSPolicy(config CORSConfig) *corsPolicy {
+	policy := &corsPolicy{
+		origins:     make(map[string]bool),
+		methods:     make(map[string]bool),
+		headers:     make(map[string]bool),
+		credentials: config.AllowCredentials,
+	}
+
+	for _, origin := range config.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			if config.AllowCredentials {
+				panic("http: CORS origin \"*\" cannot be combined with AllowCredentials")
+			}
+			policy.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			policy.wildcards = append(policy.wildcards, wildcardOrigin{prefix: prefix, suffix: suffix})
+		default:
+			policy.origins[origin] = true
+		}
+	}
+
+	methods := make([]string, 0, len(config.AllowedMethods))
+	for _, method := range config.AllowedMethods {
+		method = strings.ToUpper(method)
+		policy.methods[method] = true
+		methods = append(methods, method)
+	}
+	policy.methodList = strings.Join(methods, ", ")
+
+	for _, header := range config.AllowedHeaders {
+		if header == "*" && !config.AllowCredentials {
+			policy.anyHeader = true
+			continue
+		}
+		policy.headers[strings.ToLower(header)] = true
+	}
+
+	policy.exposed = strings.Join(config.ExposedHeaders, ", ")
+
+	if config.MaxAge > 0 {
+		policy.maxAge = strconv.Itoa(int(config.MaxAge.Seconds()))
+	}
+
+	return policy
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	if p.anyOrigin || p.origins[origin] {
+		return true
+	}
+
+	for _, wildcard := range p.wildcards {
+		if len(origin) > len(wildcard.prefix)+len(wildcard.suffix) &&
+			strings.HasPrefix(origin, wildcard.prefix) &&
+			strings.HasSuffix(origin, wildcard.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setOrigin echoes the origin unless any origin may read the response, in
+// which case "*" lets shared caches reuse it.
+func (p *corsPolicy) setOrigin(header http.Header, origin string) {
+	if p.anyOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if p.credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// preflight sets the CORS headers of a preflight response when the origin,
+// method and every requested header are allowed, and none otherwise.
+func (p *corsPolicy) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	if origin == "" || !p.allowOrigin(origin) {
+		return
+	}
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	if !p.methods[method] {
+		return
+	}
+
+	var requested []string
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if !p.anyHeader && !p.headers[strings.ToLower(header)] {
+			return
+		}
+		requested = append(requested, header)
+	}
+
+	header := w.Header()
+	p.setOrigin(header, origin)
+	header.Set("Access-Control-Allow-Methods", p.methodList)
+	if len(requested) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+	if p.maxAge != "" {
+		header.Set("Access-Control-Max-Age", p.maxAge)
+	}
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func serveCORS(config CORSConfig, r *http.Request) *httptest.ResponseRecorder {
+	handler := WithCORSPolicy(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestCORSPreflight(t *testing.T) {
+	config := CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods: []string{http.MethodGet},
+		AllowedHeaders: []string{"X-Request-ID"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	tests := []struct {
+		name       string
+		origin     string
+		method     string
+		headers    string
+		wantOrigin string
+	}{
+		{"allowed", "https://app.example.com", http.MethodGet, "x-request-id", "https://app.example.com"},
+		{"wildcard subdomain", "https://eu.example.org", http.MethodGet, "", "https://eu.example.org"},
+		{"wildcard excludes apex", "https://example.org", http.MethodGet, "", ""},
+		{"disallowed origin", "https://evil.example", http.MethodGet, "", ""},
+		{"disallowed method", "https://app.example.com", http.MethodDelete, "", ""},
+		{"disallowed header", "https://app.example.com", http.MethodGet, "X-Secret", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodOptions, "/", nil)
+			r.Header.Set("Origin", tt.origin)
+			r.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				r.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+
+			rec := serveCORS(config, r)
+
+			if rec.Code != http.StatusNoContent {
+				t.Errorf("status = %d, want 204", rec.Code)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if tt.wantOrigin == "" {
+				return
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET" {
+				t.Errorf("Allow-Methods = %q, want GET", got)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Headers"); got != tt.headers {
+				t.Errorf("Allow-Headers = %q, want %q", got, tt.headers)
+			}
+			if got := rec.Header().Get("Access-Control-Max-Age"); got != "600" {
+				t.Errorf("Max-Age = %q, want 600", got)
+			}
+		})
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	config := CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	rec := serveCORS(config, r)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Allow-Origin = %q, want the origin echoed", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Allow-Credentials = %q, want true", got)
+	}
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-ID" {
+		t.Errorf("Expose-Headers = %q, want X-Request-ID", got)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://evil.example")
+	rec = serveCORS(config, r)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("disallowed origin: status = %d, want the request served", rec.Code)
+	}
+	for _, header := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Access-Control-Expose-Headers"} {
+		if got := rec.Header().Get(header); got != "" {
+			t.Errorf("disallowed origin: %s = %q, want none", header, got)
+		}
+	}
+}
+
+func TestCORSVary(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    CORSConfig
+		preflight bool
+		want      []string
+	}{
+		{"any origin", DefaultCORSConfig(), false, nil},
+		{"listed origins", CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}, false, []string{"Origin"}},
+		{"preflight", DefaultCORSConfig(), true, []string{"Access-Control-Request-Method", "Access-Control-Request-Headers"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.preflight {
+				r.Method = http.MethodOptions
+				r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+			r.Header.Set("Origin", "https://app.example.com")
+
+			got := serveCORS(tt.config, r).Header().Values("Vary")
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Vary = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSRejectsAnyOriginWithCredentials(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("WithCORSPolicy did not panic on \"*\" with AllowCredentials")
+		}
+	}()
+
+	WithCORSPolicy(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
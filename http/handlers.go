@@ -2,8 +2,12 @@ package http
 
 import (
 	"context"
+	"errors"
+	"math"
+	"net"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/rsdlab-dk/tft-core/logger"
 	"github.com/rsdlab-dk/tft-core/ratelimit"
@@ -11,14 +15,15 @@ import (
 	"go.uber.org/zap"
 )
 
-func SummonerByRiotIDHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger) http.HandlerFunc {
-	return WithCORS(WithRateLimit(rateLimiter, "summoner", log)(func(w http.ResponseWriter, r *http.Request) {
+func SummonerByRiotIDHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, "summoner", log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(summonerByRiotID(riotClient, log)).ServeHTTP
+}
+
+func summonerByRiotID(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		gameName := r.URL.Query().Get("gameName")
 		tagLine := r.URL.Query().Get("tagLine")
-		region := r.URL.Query().Get("region")
-		if region == "" {
-			region = "br1"
-		}
+		region := queryRegion(r)
 
 		requestID := logger.GetRequestID(r.Context())
 
@@ -35,28 +40,32 @@ func SummonerByRiotIDHandler(riotClient *riot.Client, rateLimiter ratelimit.Limi
 			zap.String("tagLine", tagLine),
 			zap.String("region", region))
 
-		ctx := context.WithValue(r.Context(), "timeout", "10s")
-		result, err := riotClient.GetSummonerByRiotID(ctx, region, gameName, tagLine)
+		result, err := riotClient.GetSummonerByRiotID(r.Context(), region, gameName, tagLine)
 		if err != nil {
 			handleRiotError(err, log, w, r, requestID)
 			return
 		}
 
+		SetSource(r.Context(), region, time.Now())
+
+		SetLastModified(w, time.UnixMilli(result.RevisionDate))
+
 		log.WithContext(r.Context()).Info("summoner request successful",
 			zap.String("gameName", gameName),
 			zap.String("puuid", result.PUUID))
 
 		WriteJSON(w, result, log, r)
-	}))
+	}
 }
 
-func SummonerByPUUIDHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger) http.HandlerFunc {
-	return WithCORS(WithRateLimit(rateLimiter, "summoner", log)(func(w http.ResponseWriter, r *http.Request) {
+func SummonerByPUUIDHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, "summoner", log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(summonerByPUUID(riotClient, log)).ServeHTTP
+}
+
+func summonerByPUUID(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		puuid := r.URL.Query().Get("puuid")
-		region := r.URL.Query().Get("region")
-		if region == "" {
-			region = "br1"
-		}
+		region := queryRegion(r)
 
 		requestID := logger.GetRequestID(r.Context())
 
@@ -72,54 +81,60 @@ func SummonerByPUUIDHandler(riotClient *riot.Client, rateLimiter ratelimit.Limit
 			zap.String("puuid", puuid),
 			zap.String("region", region))
 
-		ctx := context.WithValue(r.Context(), "timeout", "10s")
-		result, err := riotClient.GetSummonerByPUUID(ctx, region, puuid)
+		result, err := riotClient.GetSummonerByPUUID(r.Context(), region, puuid)
 		if err != nil {
 			handleRiotError(err, log, w, r, requestID)
 			return
 		}
 
+		SetSource(r.Context(), region, time.Now())
+
+		SetLastModified(w, time.UnixMilli(result.RevisionDate))
+
 		log.WithContext(r.Context()).Info("summoner request successful",
 			zap.String("puuid", puuid),
 			zap.String("name", result.Name))
 
 		WriteJSON(w, result, log, r)
-	}))
+	}
 }
 
-func ChallengerLeagueHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger) http.HandlerFunc {
-	return WithCORS(WithRateLimit(rateLimiter, "league", log)(func(w http.ResponseWriter, r *http.Request) {
-		region := r.URL.Query().Get("region")
-		if region == "" {
-			region = "br1"
-		}
-
-		requestID := logger.GetRequestID(r.Context())
-
-		if !ValidateRegion(region, requestID, log, w, r) {
-			return
-		}
-
-		log.WithContext(r.Context()).Info("challenger league request",
-			zap.String("region", region))
+func handleRiotError(err error, log *logger.Logger, w http.ResponseWriter, r *http.Request, requestID string) {
+	if errors.Is(err, context.Canceled) {
+		log.WithContext(r.Context()).Info("request cancelled",
+			zap.String("request_id", requestID),
+			zap.Error(err))
+		return
+	}
 
-		ctx := context.WithValue(r.Context(), "timeout", "10s")
-		result, err := riotClient.GetChallengerLeague(ctx, region)
-		if err != nil {
-			handleRiotError(err, log, w, r, requestID)
-			return
-		}
+	if errors.Is(err, context.DeadlineExceeded) || isTimeout(err) {
+		log.WithContext(r.Context()).Warn("riot api timeout",
+			zap.String("request_id", requestID),
+			zap.Error(err))
+		WriteError(w, "GATEWAY_TIMEOUT", "Riot API timed out", http.StatusGatewayTimeout, log, r)
+		return
+	}
 
-		log.WithContext(r.Context()).Info("challenger league request successful",
-			zap.String("region", region),
-			zap.Int("players", len(result.Entries)))
+	var circuitErr *riot.CircuitOpenError
+	if errors.As(err, &circuitErr) {
+		log.WithContext(r.Context()).Warn("riot circuit open",
+			zap.String("request_id", requestID),
+			zap.Error(err))
+		setRetryAfter(w, circuitErr.RetryAfter)
+		WriteError(w, "SERVICE_UNAVAILABLE", "Riot API temporarily unavailable", http.StatusServiceUnavailable, log, r)
+		return
+	}
 
-		WriteJSON(w, result, log, r)
-	}))
-}
+	if errors.Is(err, riot.ErrQueueFull) || errors.Is(err, riot.ErrConcurrencyLimited) {
+		log.WithContext(r.Context()).Warn("riot api overloaded",
+			zap.String("request_id", requestID),
+			zap.Error(err))
+		WriteError(w, "SERVICE_UNAVAILABLE", "Riot API temporarily unavailable", http.StatusServiceUnavailable, log, r)
+		return
+	}
 
-func handleRiotError(err error, log *logger.Logger, w http.ResponseWriter, r *http.Request, requestID string) {
-	if riotErr, ok := err.(*riot.RiotError); ok {
+	var riotErr *riot.RiotError
+	if errors.As(err, &riotErr) {
 		switch {
 		case riotErr.IsNotFound():
 			log.WithContext(r.Context()).Warn("resource not found",
@@ -130,6 +145,7 @@ func handleRiotError(err error, log *logger.Logger, w http.ResponseWriter, r *ht
 			log.WithContext(r.Context()).Warn("rate limited by riot api",
 				zap.String("request_id", requestID),
 				zap.Error(err))
+			setRetryAfter(w, riotErr.RetryAfter)
 			WriteError(w, "RATE_LIMITED", "Rate limited by Riot API", http.StatusTooManyRequests, log, r)
 		case riotErr.IsUnauthorized() || riotErr.IsForbidden():
 			log.WithContext(r.Context()).Error("api key issue",
@@ -150,3 +166,17 @@ func handleRiotError(err error, log *logger.Logger, w http.ResponseWriter, r *ht
 		zap.Error(err))
 	WriteInternalError(w, log, r)
 }
+
+// setRetryAfter sets Retry-After in whole seconds, rounded up so clients never
+// retry early. A non-positive delay sets nothing.
+func setRetryAfter(w http.ResponseWriter, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
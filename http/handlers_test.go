@@ -0,0 +1,70 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"github.com/rsdlab-dk/tft-core/riot"
+)
+
+func TestExportedHandlersReportSource(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newFakeRiotClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/ids"), strings.Contains(path, "/league/v1/entries/"),
+			strings.Contains(path, "/league/v1/by-puuid/"), strings.Contains(path, "/rated-ladders/"):
+			w.Write([]byte("[]"))
+		default:
+			w.Write([]byte("{}"))
+		}
+	})
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.WithCleanupInterval(0))
+	puuid := strings.Repeat("p", 78)
+
+	tests := []struct {
+		name    string
+		handler func(*riot.Client, ratelimit.Limiter, *logger.Logger, ...RateLimitOption) http.HandlerFunc
+		target  string
+		want    string
+	}{
+		{"SummonerByRiotIDHandler", SummonerByRiotIDHandler, "/?gameName=Player&tagLine=BR1", "br1"},
+		{"SummonerByPUUIDHandler", SummonerByPUUIDHandler, "/?puuid=" + puuid, "br1"},
+		{"ChallengerLeagueHandler", ChallengerLeagueHandler, "/", "br1"},
+		{"GrandmasterLeagueHandler", GrandmasterLeagueHandler, "/", "br1"},
+		{"MasterLeagueHandler", MasterLeagueHandler, "/", "br1"},
+		{"LeagueEntriesHandler", LeagueEntriesHandler, "/?tier=DIAMOND&division=I", "br1"},
+		{"PlayerRankHandler", PlayerRankHandler, "/?puuid=" + puuid, "br1"},
+		{"RatedLadderHandler", RatedLadderHandler, "/", "br1"},
+		{"MatchHistoryHandler", MatchHistoryHandler, "/?puuid=" + puuid, "americas"},
+		{"MatchHandler", MatchHandler, "/?matchId=BR1_1234567890", "americas"},
+		{"RecentMatchesHandler", RecentMatchesHandler, "/?puuid=" + puuid, "americas"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(client, limiter, log)(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+
+			var body Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Meta == nil || body.Meta.Region != tt.want || body.Meta.FetchedAt.IsZero() {
+				t.Errorf("meta = %+v, want the source %s", body.Meta, tt.want)
+			}
+		})
+	}
+}
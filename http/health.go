@@ -0,0 +1,335 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"github.com/rsdlab-dk/tft-core/riot"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultHealthCheckTimeout = 2 * time.Second
+	// DefaultRiotCheckInterval is how long DefaultReadinessChecks reuses the
+	// outcome of a Riot call, so probes cost one call per interval per pod
+	// rather than one per probe.
+	DefaultRiotCheckInterval = 30 * time.Second
+	DefaultReadinessRegion   = "br1"
+)
+
+const (
+	HealthPass = "pass"
+	HealthWarn = "warn"
+	HealthFail = "fail"
+)
+
+// Checker reports whether a dependency can serve requests. It should honor
+// ctx, though a check that overruns its timeout is reported as failed either
+// way.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Pinger is implemented by backends with a cheap round trip, such as cache or
+// rate limiter stores.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type HealthCheck struct {
+	Name    string
+	Checker Checker
+	// Timeout bounds the check. Zero means DefaultHealthCheckTimeout.
+	Timeout time.Duration
+	// Optional checks are reported as "warn" when they fail but keep the
+	// service ready, for dependencies shared by every replica whose outage
+	// should not take them all out of rotation.
+	Optional bool
+}
+
+type HealthReport struct {
+	Status    string        `json:"status"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+// CheckResult is served without authentication, so Error only says whether
+// the check failed or timed out. The underlying error, which may name
+// upstream URLs or store addresses, is logged instead.
+type CheckResult struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+
+	detail string
+}
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// ReadBuildInfo describes the running binary from the module and VCS data Go
+// embeds at build time. Binaries built outside a checkout, or with
+// -buildvcs=false, have no commit.
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{Version: "(devel)", GoVersion: runtime.Version()}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	if build.Main.Version != "" {
+		info.Version = build.Main.Version
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.BuildTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+}
+
+// RiotChecker calls the platform status endpoint of region. Any answer from
+// Riot other than 401 or 403, which mean the API key is unusable, shows it is
+// reachable: a 429 is transient and must not take every replica out of
+// rotation at once. The call goes through the client's scheduler and breaker
+// like any other, so an open circuit fails the check, but at bulk priority so
+// it never spends the interactive reserve. Wrap it in CachedChecker to bound
+// how much of the key's budget probes use.
+func RiotChecker(client *riot.Client, region string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		_, err := client.GetPlatformStatus(riot.WithPriority(ctx, riot.PriorityBulk), region)
+
+		var riotErr *riot.RiotError
+		if errors.As(err, &riotErr) && !riotErr.IsUnauthorized() && !riotErr.IsForbidden() {
+			return nil
+		}
+		return err
+	})
+}
+
+// RateLimiterChecker pings limiters that implement Pinger and otherwise reads
+// a probe key, which costs a round trip to remote stores and nothing to the
+// in-memory one.
+func RateLimiterChecker(limiter ratelimit.Limiter) Checker {
+	if pinger, ok := limiter.(Pinger); ok {
+		return PingChecker(pinger)
+	}
+
+	return CheckerFunc(func(ctx context.Context) error {
+		_, err := limiter.GetCount(ctx, "health:probe")
+		return err
+	})
+}
+
+// PingChecker checks a backend such as a response cache through its Ping.
+func PingChecker(pinger Pinger) Checker {
+	return CheckerFunc(pinger.Ping)
+}
+
+// CachedChecker reuses the outcome of checker, pass or fail, for interval.
+// Probes arriving together while the outcome is stale may each run the check.
+func CachedChecker(checker Checker, interval time.Duration) Checker {
+	var (
+		mu        sync.Mutex
+		checkedAt time.Time
+		lastErr   error
+	)
+
+	return CheckerFunc(func(ctx context.Context) error {
+		mu.Lock()
+		if !checkedAt.IsZero() && time.Since(checkedAt) < interval {
+			err := lastErr
+			mu.Unlock()
+			return err
+		}
+		mu.Unlock()
+
+		err := checker.Check(ctx)
+		if ctx.Err() != nil {
+			// A probe that gave up says nothing about the dependency.
+			return err
+		}
+
+		mu.Lock()
+		checkedAt, lastErr = time.Now(), err
+		mu.Unlock()
+		return err
+	})
+}
+
+// DefaultReadinessChecks checks that Riot is reachable in region, at most
+// once per DefaultRiotCheckInterval, and that the rate limiter backend
+// answers. The Riot check is optional: every replica shares Riot, so its
+// outage or an open breaker would otherwise take them all out of rotation at
+// once. An empty region means DefaultReadinessRegion.
+func DefaultReadinessChecks(riotClient *riot.Client, rateLimiter ratelimit.Limiter, region string) []HealthCheck {
+	if region == "" {
+		region = DefaultReadinessRegion
+	}
+	return []HealthCheck{
+		{Name: "riot", Checker: CachedChecker(RiotChecker(riotClient, region), DefaultRiotCheckInterval), Optional: true},
+		{Name: "ratelimit", Checker: RateLimiterChecker(rateLimiter)},
+	}
+}
+
+// LivenessHandler reports that the process is serving. It checks no
+// dependency, so an outage elsewhere never gets the pod restarted.
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, HealthReport{
+			Status:    HealthPass,
+			CheckedAt: time.Now().UTC(),
+			Checks:    []CheckResult{},
+		})
+	}
+}
+
+// ReadinessHandler runs every check concurrently, each under its own
+// timeout, and answers 503 when a required check fails and 200 otherwise.
+// The report lists each check, so the same endpoint serves probes and
+// dashboards.
+func ReadinessHandler(checks []HealthCheck, log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := runChecks(r.Context(), checks)
+
+		for _, result := range report.Checks {
+			if result.Status != HealthPass {
+				log.WithContext(r.Context()).Warn("readiness check failed",
+					zap.String("check", result.Name),
+					zap.Bool("optional", result.Status == HealthWarn),
+					zap.String("error", result.detail),
+					zap.Float64("duration_ms", result.DurationMS))
+			}
+		}
+
+		status := http.StatusOK
+		if report.Status == HealthFail {
+			status = http.StatusServiceUnavailable
+		}
+
+		writeHealth(w, status, report)
+	}
+}
+
+func VersionHandler(info BuildInfo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, info)
+	}
+}
+
+func runChecks(ctx context.Context, checks []HealthCheck) HealthReport {
+	report := HealthReport{
+		Status:    HealthPass,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make([]CheckResult, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		switch {
+		case result.Status == HealthFail:
+			report.Status = HealthFail
+		case result.Status == HealthWarn && report.Status == HealthPass:
+			report.Status = HealthWarn
+		}
+	}
+
+	return report
+}
+
+// runCheck runs the checker on its own goroutine so a check that ignores ctx
+// still fails on time; its result is then discarded.
+func runCheck(ctx context.Context, check HealthCheck) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("check panicked: %v", recovered)
+			}
+		}()
+		done <- check.Checker.Check(ctx)
+	}()
+
+	var err error
+	public := "check failed"
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+			public = err.Error()
+		}
+	}
+
+	result := CheckResult{
+		Name:       check.Name,
+		Status:     HealthPass,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = HealthFail
+		if check.Optional {
+			result.Status = HealthWarn
+		}
+		result.Error = public
+		result.detail = err.Error()
+	}
+
+	return result
+}
+
+// writeHealth writes the bare report rather than the Response envelope,
+// which is what probes and monitoring tools expect. It is never cached.
+func writeHealth(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"github.com/rsdlab-dk/tft-core/riot"
+)
+
+func TestCachedChecker(t *testing.T) {
+	calls := 0
+	checker := CachedChecker(CheckerFunc(func(ctx context.Context) error {
+		calls++
+		return errors.New("down")
+	}), time.Hour)
+
+	for range 3 {
+		if err := checker.Check(context.Background()); err == nil {
+			t.Fatal("cached failure should still be reported")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("checker ran %d times, want 1", calls)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	abandoned := CachedChecker(CheckerFunc(func(ctx context.Context) error {
+		calls++
+		return ctx.Err()
+	}), time.Hour)
+	abandoned.Check(canceled)
+	if err := abandoned.Check(context.Background()); err != nil {
+		t.Errorf("a canceled probe should not be cached, got %v", err)
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pass := CheckerFunc(func(ctx context.Context) error { return nil })
+	fail := CheckerFunc(func(ctx context.Context) error { return errors.New("down") })
+	hang := CheckerFunc(func(ctx context.Context) error { time.Sleep(time.Second); return nil })
+
+	tests := []struct {
+		name       string
+		checks     []HealthCheck
+		wantCode   int
+		wantStatus string
+	}{
+		{"all pass", []HealthCheck{{Name: "a", Checker: pass}}, http.StatusOK, HealthPass},
+		{"required fails", []HealthCheck{{Name: "a", Checker: pass}, {Name: "b", Checker: fail}}, http.StatusServiceUnavailable, HealthFail},
+		{"optional fails", []HealthCheck{{Name: "a", Checker: pass}, {Name: "b", Checker: fail, Optional: true}}, http.StatusOK, HealthWarn},
+		{"times out", []HealthCheck{{Name: "a", Checker: hang, Timeout: 10 * time.Millisecond}}, http.StatusServiceUnavailable, HealthFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ReadinessHandler(tt.checks, log)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d", rec.Code, tt.wantCode)
+			}
+
+			var report HealthReport
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("decoding report: %v", err)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantStatus)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("got %d check results, want %d", len(report.Checks), len(tt.checks))
+			}
+			for _, result := range report.Checks {
+				if strings.Contains(result.Error, "down") {
+					t.Errorf("check %s exposes its error %q", result.Name, result.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultReadinessChecksRiotOptional(t *testing.T) {
+	checks := DefaultReadinessChecks(riot.NewClient("test-key"), ratelimit.NewMemoryLimiter(ratelimit.WithCleanupInterval(0)), "")
+
+	for _, check := range checks {
+		if want := check.Name == "riot"; check.Optional != want {
+			t.Errorf("check %s: optional = %v, want %v", check.Name, check.Optional, want)
+		}
+	}
+}
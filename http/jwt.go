@@ -0,0 +1,231 @@
+package http
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JWTConfig verifies bearer tokens signed with HS256/384/512 or
+// RS256/384/512. Only the algorithm families with a configured key are
+// accepted, so an HMAC token can never be checked against an RSA public key.
+type JWTConfig struct {
+	HMACSecret []byte
+	// RSAKeys are public keys by "kid". A key stored under "" verifies tokens
+	// without a kid.
+	RSAKeys map[string]*rsa.PublicKey
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// Leeway absorbs clock skew when checking exp and nbf.
+	Leeway time.Duration
+	// PlanClaim names a string claim carrying the rate-limit plan.
+	PlanClaim string
+}
+
+type jwtAuthenticator struct {
+	config JWTConfig
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Issuer    string      `json:"iss"`
+	Subject   string      `json:"sub"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt *float64    `json:"exp"`
+	NotBefore *float64    `json:"nbf"`
+}
+
+// jwtAudience accepts aud as a single string or a list, as RFC 7519 allows.
+type jwtAudience []string
+
+var jwtHashes = map[string]crypto.Hash{
+	"256": crypto.SHA256,
+	"384": crypto.SHA384,
+	"512": crypto.SHA512,
+}
+
+func NewJWTAuthenticator(config JWTConfig) (Authenticator, error) {
+	if len(config.HMACSecret) == 0 && len(config.RSAKeys) == 0 {
+		return nil, errors.New("jwt: an HMAC secret or RSA key is required")
+	}
+	return &jwtAuthenticator{config: config}, nil
+}
+
+// ParseRSAPublicKeyPEM reads a PKIX ("PUBLIC KEY") or PKCS#1
+// ("RSA PUBLIC KEY") PEM block.
+func ParseRSAPublicKeyPEM(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jwt: no PEM block found")
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: parsing public key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("jwt: public key is not RSA")
+	}
+	return rsaKey, nil
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, nil
+	}
+
+	principal, err := a.verify(strings.TrimSpace(token), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("jwt: %w: %v", ErrInvalidCredentials, err)
+	}
+
+	return principal, nil
+}
+
+func (a *jwtAuthenticator) verify(token string, now time.Time) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+
+	if err := a.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("missing exp")
+	}
+	if now.After(time.Unix(int64(*claims.ExpiresAt), 0).Add(a.config.Leeway)) {
+		return nil, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(a.config.Leeway).Before(time.Unix(int64(*claims.NotBefore), 0)) {
+		return nil, errors.New("token not yet valid")
+	}
+	if a.config.Issuer != "" && claims.Issuer != a.config.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if a.config.Audience != "" && !claims.Audience.contains(a.config.Audience) {
+		return nil, errors.New("audience mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("missing sub")
+	}
+
+	principal := &Principal{ID: claims.Subject, Method: "jwt"}
+
+	if a.config.PlanClaim != "" {
+		var extra map[string]any
+		if err := decodeSegment(parts[1], &extra); err == nil {
+			principal.Plan, _ = extra[a.config.PlanClaim].(string)
+		}
+	}
+
+	return principal, nil
+}
+
+func (a *jwtAuthenticator) verifySignature(header jwtHeader, signed string, signature []byte) error {
+	if len(header.Alg) != 5 {
+		return fmt.Errorf("unsupported alg %q", header.Alg)
+	}
+
+	hash, ok := jwtHashes[header.Alg[2:]]
+	if !ok {
+		return fmt.Errorf("unsupported alg %q", header.Alg)
+	}
+
+	switch header.Alg[:2] {
+	case "HS":
+		if len(a.config.HMACSecret) == 0 {
+			return fmt.Errorf("alg %s not accepted", header.Alg)
+		}
+		mac := hmac.New(hash.New, a.config.HMACSecret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+
+	case "RS":
+		key, ok := a.config.RSAKeys[header.Kid]
+		if !ok {
+			return fmt.Errorf("unknown key %q", header.Kid)
+		}
+		digest := hash.New()
+		digest.Write([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, hash, digest.Sum(nil), signature); err != nil {
+			return errors.New("invalid signature")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported alg %q", header.Alg)
+	}
+}
+
+func (aud *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = jwtAudience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("aud must be a string or a list of strings")
+	}
+	*aud = list
+	return nil
+}
+
+func (aud jwtAudience) contains(audience string) bool {
+	for _, a := range aud {
+		if a == audience {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,319 @@
+package http
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testHMACSecret = []byte("test-secret-with-enough-entropy!")
+
+func signJWT(t *testing.T, header, claims map[string]any, sign func(signed string) []byte) string {
+	t.Helper()
+
+	encode := func(v map[string]any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := encode(header) + "." + encode(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(signed))
+}
+
+func hs256(signed string) []byte {
+	mac := hmac.New(sha256.New, testHMACSecret)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+func rs256(t *testing.T, key *rsa.PrivateKey) func(string) []byte {
+	return func(signed string) []byte {
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+
+	now := time.Now()
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub":  "user-1",
+			"iss":  "https://auth.example.com",
+			"aud":  "tft-api",
+			"exp":  now.Add(time.Hour).Unix(),
+			"plan": "pro",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+	hsHeader := map[string]any{"alg": "HS256", "typ": "JWT"}
+	rsHeader := map[string]any{"alg": "RS256", "kid": "k1"}
+
+	hsOnly := JWTConfig{HMACSecret: testHMACSecret, Issuer: "https://auth.example.com", Audience: "tft-api", PlanClaim: "plan"}
+	rsOnly := JWTConfig{RSAKeys: map[string]*rsa.PublicKey{"k1": &rsaKey.PublicKey}, Audience: "tft-api"}
+
+	tests := []struct {
+		name    string
+		config  JWTConfig
+		token   string
+		wantSub string
+	}{
+		{
+			name:    "valid HS256",
+			config:  hsOnly,
+			token:   signJWT(t, hsHeader, claims(nil), hs256),
+			wantSub: "user-1",
+		},
+		{
+			name:    "valid RS256",
+			config:  rsOnly,
+			token:   signJWT(t, rsHeader, claims(nil), rs256(t, rsaKey)),
+			wantSub: "user-1",
+		},
+		{
+			name:    "aud as list",
+			config:  hsOnly,
+			token:   signJWT(t, hsHeader, claims(map[string]any{"aud": []string{"other", "tft-api"}}), hs256),
+			wantSub: "user-1",
+		},
+		{
+			name:    "expired within leeway",
+			config:  JWTConfig{HMACSecret: testHMACSecret, Leeway: time.Minute},
+			token:   signJWT(t, hsHeader, claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()}), hs256),
+			wantSub: "user-1",
+		},
+		{
+			name:   "alg none",
+			config: hsOnly,
+			token:  signJWT(t, map[string]any{"alg": "none"}, claims(nil), func(string) []byte { return nil }),
+		},
+		{
+			name:   "HS256 signed with the RSA public key",
+			config: rsOnly,
+			token: signJWT(t, hsHeader, claims(nil), func(signed string) []byte {
+				mac := hmac.New(sha256.New, publicPEM)
+				mac.Write([]byte(signed))
+				return mac.Sum(nil)
+			}),
+		},
+		{
+			name:   "RS256 without RSA keys",
+			config: hsOnly,
+			token:  signJWT(t, rsHeader, claims(nil), rs256(t, rsaKey)),
+		},
+		{
+			name:   "unsupported alg",
+			config: hsOnly,
+			token:  signJWT(t, map[string]any{"alg": "ES256"}, claims(nil), hs256),
+		},
+		{
+			name:   "unknown kid",
+			config: rsOnly,
+			token:  signJWT(t, map[string]any{"alg": "RS256", "kid": "k2"}, claims(nil), rs256(t, rsaKey)),
+		},
+		{
+			name:   "signed by another key",
+			config: rsOnly,
+			token:  signJWT(t, rsHeader, claims(nil), rs256(t, otherKey)),
+		},
+		{
+			name:   "wrong HMAC secret",
+			config: JWTConfig{HMACSecret: []byte("another-secret")},
+			token:  signJWT(t, hsHeader, claims(nil), hs256),
+		},
+		{
+			name:   "expired",
+			config: hsOnly,
+			token:  signJWT(t, hsHeader, claims(map[string]any{"exp": now.Add(-time.Minute).Unix()}), hs256),
+		},
+		{
+			name:   "expired beyond leeway",
+			config: JWTConfig{HMACSecret: testHMACSecret, Leeway: time.Minute},
+			token:  signJWT(t, hsHeader, claims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()}), hs256),
+		},
+		{
+			name:   "missing exp",
+			config: hsOnly,
+			token:  signJWT(t, hsHeader, claims(map[string]any{"exp": nil}), hs256),
+		},
+		{
+			name:   "not yet valid",
+			config: hsOnly,
+			token:  signJWT(t, hsHeader, claims(map[string]any{"nbf": now.Add(time.Hour).Unix()}), hs256),
+		},
+		{
+			name:   "wrong issuer",
+			config: hsOnly,
+			token:  signJWT(t, hsHeader, claims(map[string]any{"iss": "https://evil.example.com"}), hs256),
+		},
+		{
+			name:   "wrong audience",
+			config: hsOnly,
+			token:  signJWT(t, hsHeader, claims(map[string]any{"aud": "other"}), hs256),
+		},
+		{
+			name:   "audience list without ours",
+			config: hsOnly,
+			token:  signJWT(t, hsHeader, claims(map[string]any{"aud": []string{"a", "b"}}), hs256),
+		},
+		{
+			name:   "missing sub",
+			config: hsOnly,
+			token:  signJWT(t, hsHeader, claims(map[string]any{"sub": nil}), hs256),
+		},
+		{
+			name:   "malformed",
+			config: hsOnly,
+			token:  "not.a-token",
+		},
+		{
+			name:   "tampered claims",
+			config: hsOnly,
+			token: func() string {
+				valid := strings.Split(signJWT(t, hsHeader, claims(nil), hs256), ".")
+				forged := strings.Split(signJWT(t, hsHeader, claims(map[string]any{"sub": "admin"}), hs256), ".")
+				return forged[0] + "." + forged[1] + "." + valid[2]
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator, err := NewJWTAuthenticator(tt.config)
+			if err != nil {
+				t.Fatalf("NewJWTAuthenticator: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			principal, err := authenticator.Authenticate(req)
+			if tt.wantSub == "" {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+				}
+				if principal != nil {
+					t.Errorf("principal = %+v, want nil", principal)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if principal.ID != tt.wantSub || principal.Method != "jwt" {
+				t.Errorf("principal = %+v, want %s via jwt", principal, tt.wantSub)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticatorPlanClaim(t *testing.T) {
+	authenticator, err := NewJWTAuthenticator(JWTConfig{HMACSecret: testHMACSecret, PlanClaim: "plan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := signJWT(t, map[string]any{"alg": "HS256"}, map[string]any{
+		"sub":  "user-1",
+		"exp":  float64(time.Now().Add(time.Hour).Unix()) + 0.5,
+		"plan": "pro",
+	}, hs256)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "bearer "+token)
+
+	principal, err := authenticator.Authenticate(req)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if principal.Plan != "pro" {
+		t.Errorf("plan = %q, want pro", principal.Plan)
+	}
+}
+
+func TestJWTAuthenticatorIgnoresOtherSchemes(t *testing.T) {
+	authenticator, err := NewJWTAuthenticator(JWTConfig{HMACSecret: testHMACSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, header := range []string{"", "Basic dXNlcjpwYXNz", "Bearer"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", header)
+
+		principal, err := authenticator.Authenticate(req)
+		if principal != nil || err != nil {
+			t.Errorf("Authorization %q: got %+v, %v; want nil, nil", header, principal, err)
+		}
+	}
+}
+
+func TestNewJWTAuthenticatorRequiresKey(t *testing.T) {
+	if _, err := NewJWTAuthenticator(JWTConfig{}); err == nil {
+		t.Error("want an error without keys")
+	}
+}
+
+func TestParseRSAPublicKeyPEM(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, block := range []*pem.Block{
+		{Type: "PUBLIC KEY", Bytes: pkix},
+		{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)},
+	} {
+		parsed, err := ParseRSAPublicKeyPEM(pem.EncodeToMemory(block))
+		if err != nil {
+			t.Fatalf("%s: %v", block.Type, err)
+		}
+		if !parsed.Equal(&key.PublicKey) {
+			t.Errorf("%s: parsed a different key", block.Type)
+		}
+	}
+
+	if _, err := ParseRSAPublicKeyPEM([]byte("not pem")); err == nil {
+		t.Error("want an error for input without a PEM block")
+	}
+}
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const (
+	DefaultIPv6PrefixLen   = 64
+	DefaultForwardedHeader = "X-Forwarded-For"
+)
+
+var ErrNoRateLimitKey = errors.New("no rate limit key for request")
+
+// KeyFunc identifies the client a request is counted against. Keys are
+// prefixed with their class ("ip:", "apikey:", "user:") so buckets from
+// different extractors never collide.
+type KeyFunc func(r *http.Request) (string, error)
+
+type ClientIPConfig struct {
+	// TrustedProxies are the networks whose ForwardedHeader is believed.
+	// Without any, only RemoteAddr is used.
+	TrustedProxies []netip.Prefix
+	// ForwardedHeader is the one header the proxies set: "Forwarded" (RFC
+	// 7239) or a comma-separated list such as X-Forwarded-For. Any other
+	// forwarding header is ignored, since clients can send it themselves.
+	// Empty means DefaultForwardedHeader.
+	ForwardedHeader string
+	// IPv6PrefixLen groups IPv6 clients by network, since a single host
+	// usually controls a whole /64. Zero means DefaultIPv6PrefixLen.
+	IPv6PrefixLen int
+}
+
+func ClientIPKey(config ClientIPConfig) KeyFunc {
+	prefixLen := config.IPv6PrefixLen
+	if prefixLen == 0 {
+		prefixLen = DefaultIPv6PrefixLen
+	}
+	header := config.ForwardedHeader
+	if header == "" {
+		header = DefaultForwardedHeader
+	}
+
+	return func(r *http.Request) (string, error) {
+		addr, ok := clientIP(r, config.TrustedProxies, header)
+		if !ok {
+			return "", ErrNoRateLimitKey
+		}
+
+		if addr.Is6() && prefixLen < 128 {
+			prefix, err := addr.Prefix(prefixLen)
+			if err != nil {
+				return "", err
+			}
+			return "ip:" + prefix.String(), nil
+		}
+
+		return "ip:" + addr.String(), nil
+	}
+}
+
+func APIKeyHeaderKey(header string) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		apiKey := r.Header.Get(header)
+		if apiKey == "" {
+			return "", ErrNoRateLimitKey
+		}
+
+		sum := sha256.Sum256([]byte(apiKey))
+		return "apikey:" + hex.EncodeToString(sum[:8]), nil
+	}
+}
+
+func UserIDKey() KeyFunc {
+	return func(r *http.Request) (string, error) {
+		userID := UserIDFromContext(r.Context())
+		if userID == "" {
+			return "", ErrNoRateLimitKey
+		}
+		return "user:" + userID, nil
+	}
+}
+
+// CompositeKey joins the keys of every extractor, failing if any of them does.
+func CompositeKey(keyFuncs ...KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		keys := make([]string, 0, len(keyFuncs))
+		for _, keyFunc := range keyFuncs {
+			key, err := keyFunc(r)
+			if err != nil {
+				return "", err
+			}
+			keys = append(keys, key)
+		}
+		return strings.Join(keys, "+"), nil
+	}
+}
+
+// FirstKey returns the key of the first extractor that succeeds, e.g. the
+// authenticated user and then the client IP for anonymous requests.
+func FirstKey(keyFuncs ...KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		for _, keyFunc := range keyFuncs {
+			if key, err := keyFunc(r); err == nil {
+				return key, nil
+			}
+		}
+		return "", ErrNoRateLimitKey
+	}
+}
+
+// clientIP walks the hops in header from the nearest, skipping trusted
+// proxies, and stops at the first hop that is untrusted or unparseable.
+func clientIP(r *http.Request, trusted []netip.Prefix, header string) (netip.Addr, bool) {
+	remote, ok := parseIP(r.RemoteAddr)
+	if !ok {
+		return netip.Addr{}, false
+	}
+
+	if !isTrusted(remote, trusted) {
+		return remote, true
+	}
+
+	var hops []string
+	if strings.EqualFold(header, "Forwarded") {
+		hops = forwardedFor(r)
+	} else {
+		hops = headerList(r, header)
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseIP(hops[i])
+		if !ok {
+			break
+		}
+
+		client = addr
+		if !isTrusted(addr, trusted) {
+			break
+		}
+	}
+
+	return client, true
+}
+
+func forwardedFor(r *http.Request) []string {
+	var hops []string
+	for _, value := range r.Header.Values("Forwarded") {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				name, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if found && strings.EqualFold(name, "for") {
+					hops = append(hops, strings.Trim(val, `"`))
+				}
+			}
+		}
+	}
+	return hops
+}
+
+func headerList(r *http.Request, header string) []string {
+	var hops []string
+	for _, value := range r.Header.Values(header) {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+func parseIP(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIPKey(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}
+
+	tests := []struct {
+		name      string
+		config    ClientIPConfig
+		remote    string
+		forwarded string
+		xff       string
+		want      string
+	}{
+		{
+			name:   "no trusted proxies ignores headers",
+			remote: "203.0.113.7:1234",
+			xff:    "198.51.100.99",
+			want:   "ip:203.0.113.7",
+		},
+		{
+			name:   "untrusted hop ignores headers",
+			config: ClientIPConfig{TrustedProxies: trusted},
+			remote: "192.0.2.1:1234",
+			xff:    "198.51.100.99",
+			want:   "ip:192.0.2.1",
+		},
+		{
+			name:   "trusted hop reads X-Forwarded-For",
+			config: ClientIPConfig{TrustedProxies: trusted},
+			remote: "10.0.0.5:1234",
+			xff:    "203.0.113.7",
+			want:   "ip:203.0.113.7",
+		},
+		{
+			name:   "spoofed leading hops are skipped",
+			config: ClientIPConfig{TrustedProxies: trusted},
+			remote: "10.0.0.5:1234",
+			xff:    "198.51.100.99, 203.0.113.7, 10.0.0.9",
+			want:   "ip:203.0.113.7",
+		},
+		{
+			name:      "client Forwarded ignored when proxy sets X-Forwarded-For",
+			config:    ClientIPConfig{TrustedProxies: trusted},
+			remote:    "10.0.0.5:1234",
+			forwarded: "for=198.51.100.99",
+			xff:       "203.0.113.7",
+			want:      "ip:203.0.113.7",
+		},
+		{
+			name:      "client X-Forwarded-For ignored when proxy sets Forwarded",
+			config:    ClientIPConfig{TrustedProxies: trusted, ForwardedHeader: "Forwarded"},
+			remote:    "10.0.0.5:1234",
+			forwarded: "for=203.0.113.7;proto=https",
+			xff:       "198.51.100.99",
+			want:      "ip:203.0.113.7",
+		},
+		{
+			name:   "no fallthrough to the other header",
+			config: ClientIPConfig{TrustedProxies: trusted, ForwardedHeader: "Forwarded"},
+			remote: "10.0.0.5:1234",
+			xff:    "198.51.100.99",
+			want:   "ip:10.0.0.5",
+		},
+		{
+			name:      "IPv6 with port in Forwarded",
+			config:    ClientIPConfig{TrustedProxies: trusted, ForwardedHeader: "Forwarded"},
+			remote:    "[fd00::1]:443",
+			forwarded: `for="[2001:db8:1:2::3]:4711"`,
+			want:      "ip:2001:db8:1:2::/64",
+		},
+		{
+			name:   "IPv6 remote without trusted proxies",
+			remote: "[2001:db8:1:2::3]:443",
+			want:   "ip:2001:db8:1:2::/64",
+		},
+		{
+			name:   "full IPv6 address with prefix length 128",
+			config: ClientIPConfig{IPv6PrefixLen: 128},
+			remote: "[2001:db8::3]:443",
+			want:   "ip:2001:db8::3",
+		},
+		{
+			name:   "IPv4-mapped remote",
+			remote: "[::ffff:203.0.113.7]:443",
+			want:   "ip:203.0.113.7",
+		},
+		{
+			name:   "unparseable hop stops at the last trusted hop",
+			config: ClientIPConfig{TrustedProxies: trusted},
+			remote: "10.0.0.5:1234",
+			xff:    "203.0.113.7, garbage",
+			want:   "ip:10.0.0.5",
+		},
+		{
+			name:      "obfuscated Forwarded hop",
+			config:    ClientIPConfig{TrustedProxies: trusted, ForwardedHeader: "Forwarded"},
+			remote:    "10.0.0.5:1234",
+			forwarded: "for=_hidden",
+			want:      "ip:10.0.0.5",
+		},
+		{
+			name:   "all hops trusted",
+			config: ClientIPConfig{TrustedProxies: trusted},
+			remote: "10.0.0.5:1234",
+			xff:    "10.0.0.7, 10.0.0.6",
+			want:   "ip:10.0.0.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("Forwarded", tt.forwarded)
+			}
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+
+			got, err := ClientIPKey(tt.config)(r)
+			if err != nil {
+				t.Fatalf("ClientIPKey: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPKeyUnparseableRemote(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "not-an-ip"
+
+	if _, err := ClientIPKey(ClientIPConfig{})(r); err != ErrNoRateLimitKey {
+		t.Errorf("err = %v, want ErrNoRateLimitKey", err)
+	}
+}
@@ -0,0 +1,310 @@
+package http
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"github.com/rsdlab-dk/tft-core/riot"
+	"go.uber.org/zap"
+)
+
+const defaultRatedQueue = "RANKED_TFT_TURBO"
+
+var validRatedQueues = map[string]bool{
+	"RANKED_TFT_TURBO": true,
+}
+
+type rankedEntry interface {
+	LP() int
+	GamesPlayed() int
+	WinRate() float64
+}
+
+func ChallengerLeagueHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, "league", log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(challengerLeague(riotClient, log)).ServeHTTP
+}
+
+func GrandmasterLeagueHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, "league", log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(grandmasterLeague(riotClient, log)).ServeHTTP
+}
+
+func MasterLeagueHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, "league", log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(masterLeague(riotClient, log)).ServeHTTP
+}
+
+func challengerLeague(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return apexLeague("challenger", riotClient.GetChallengerLeague, log)
+}
+
+func grandmasterLeague(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return apexLeague("grandmaster", riotClient.GetGrandmasterLeague, log)
+}
+
+func masterLeague(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return apexLeague("master", riotClient.GetMasterLeague, log)
+}
+
+func apexLeague(tier string, fetch func(context.Context, string) (*riot.LeagueList, error), log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		region := queryRegion(r)
+
+		requestID := logger.GetRequestID(r.Context())
+
+		if !ValidateRegion(region, requestID, log, w, r) {
+			return
+		}
+
+		query, ok := ParseLeagueQuery(requestID, log, w, r)
+		if !ok {
+			return
+		}
+
+		log.WithContext(r.Context()).Info(tier+" league request",
+			zap.String("region", region),
+			zap.String("sort", query.Sort),
+			zap.Int("page", query.Page))
+
+		result, err := fetch(r.Context(), region)
+		if err != nil {
+			handleRiotError(err, log, w, r, requestID)
+			return
+		}
+
+		SetSource(r.Context(), region, time.Now())
+
+		log.WithContext(r.Context()).Info(tier+" league request successful",
+			zap.String("region", region),
+			zap.Int("players", len(result.Entries)))
+
+		league := *result
+		var total int
+		league.Entries, total = applyLeagueQuery(result.Entries, query)
+		setPagePagination(r.Context(), query.Page, query.PageSize, total)
+
+		WriteJSON(w, league, log, r)
+	}
+}
+
+func LeagueEntriesHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, "league", log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(leagueEntries(riotClient, log)).ServeHTTP
+}
+
+// leagueEntries serves one Riot page of a tier and division, selected with
+// riotPage, and then pages the filtered result with page and pageSize.
+func leagueEntries(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tier := strings.ToUpper(r.URL.Query().Get("tier"))
+		division := strings.ToUpper(r.URL.Query().Get("division"))
+		region := queryRegion(r)
+
+		requestID := logger.GetRequestID(r.Context())
+
+		if !ValidateRegion(region, requestID, log, w, r) {
+			return
+		}
+
+		if !ValidateTierDivision(tier, division, requestID, log, w, r) {
+			return
+		}
+
+		riotPage := 1
+		if raw := r.URL.Query().Get("riotPage"); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value < 1 {
+				log.WithContext(r.Context()).Warn("invalid riotPage parameter",
+					zap.String("request_id", requestID),
+					zap.String("riotPage", raw))
+				WriteBadRequest(w, "Invalid riotPage parameter", log, r)
+				return
+			}
+			riotPage = value
+		}
+
+		query, ok := ParseLeagueQuery(requestID, log, w, r)
+		if !ok {
+			return
+		}
+
+		log.WithContext(r.Context()).Info("league entries request",
+			zap.String("region", region),
+			zap.String("tier", tier),
+			zap.String("division", division),
+			zap.Int("riotPage", riotPage))
+
+		result, err := riotClient.GetLeagueEntriesByTierPage(r.Context(), region, tier, division, riotPage)
+		if err != nil {
+			handleRiotError(err, log, w, r, requestID)
+			return
+		}
+
+		SetSource(r.Context(), region, time.Now())
+
+		log.WithContext(r.Context()).Info("league entries request successful",
+			zap.String("tier", tier),
+			zap.String("division", division),
+			zap.Int("players", len(result)))
+
+		entries, total := applyLeagueQuery(result, query)
+		setPagePagination(r.Context(), query.Page, query.PageSize, total)
+
+		WriteJSON(w, entries, log, r)
+	}
+}
+
+func PlayerRankHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, "league", log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(playerRank(riotClient, log)).ServeHTTP
+}
+
+func playerRank(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		puuid := r.URL.Query().Get("puuid")
+		region := queryRegion(r)
+
+		requestID := logger.GetRequestID(r.Context())
+
+		if !ValidatePUUID(puuid, requestID, log, w, r) {
+			return
+		}
+
+		if !ValidateRegion(region, requestID, log, w, r) {
+			return
+		}
+
+		log.WithContext(r.Context()).Info("player rank request",
+			zap.String("puuid", puuid),
+			zap.String("region", region))
+
+		result, err := riotClient.GetLeagueEntriesByPUUID(r.Context(), region, puuid)
+		if err != nil {
+			handleRiotError(err, log, w, r, requestID)
+			return
+		}
+
+		SetSource(r.Context(), region, time.Now())
+
+		log.WithContext(r.Context()).Info("player rank request successful",
+			zap.String("puuid", puuid),
+			zap.Int("queues", len(result)))
+
+		WriteJSON(w, result, log, r)
+	}
+}
+
+func RatedLadderHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, "league", log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(ratedLadder(riotClient, log)).ServeHTTP
+}
+
+// ratedLadder serves the top of a rated queue such as Hyper Roll. Riot
+// already orders the ladder by rating, so only pagination applies.
+func ratedLadder(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queue := strings.ToUpper(r.URL.Query().Get("queue"))
+		if queue == "" {
+			queue = defaultRatedQueue
+		}
+		region := queryRegion(r)
+
+		requestID := logger.GetRequestID(r.Context())
+
+		if !ValidateRegion(region, requestID, log, w, r) {
+			return
+		}
+
+		if !validRatedQueues[queue] {
+			log.WithContext(r.Context()).Warn("invalid rated queue",
+				zap.String("request_id", requestID),
+				zap.String("queue", queue))
+			WriteBadRequest(w, "Invalid queue", log, r)
+			return
+		}
+
+		pageNumber, pageSize, ok := ParsePagination(requestID, log, w, r)
+		if !ok {
+			return
+		}
+
+		log.WithContext(r.Context()).Info("rated ladder request",
+			zap.String("region", region),
+			zap.String("queue", queue))
+
+		result, err := riotClient.GetRatedLadder(r.Context(), region, queue)
+		if err != nil {
+			handleRiotError(err, log, w, r, requestID)
+			return
+		}
+
+		SetSource(r.Context(), region, time.Now())
+
+		log.WithContext(r.Context()).Info("rated ladder request successful",
+			zap.String("queue", queue),
+			zap.Int("players", len(result)))
+
+		setPagePagination(r.Context(), pageNumber, pageSize, len(result))
+
+		WriteJSON(w, paginate(result, pageNumber, pageSize), log, r)
+	}
+}
+
+// applyLeagueQuery returns the requested page of entries that pass the
+// query's filters, or all of them when the query is unpaged, along with how
+// many passed in total. Ties keep Riot's order.
+func applyLeagueQuery[T rankedEntry](entries []T, query LeagueQuery) ([]T, int) {
+	filtered := make([]T, 0, len(entries))
+	for _, e := range entries {
+		if e.LP() < query.MinLP || e.GamesPlayed() < query.MinGames || e.WinRate() < query.MinWinRate {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+
+	key := func(e T) float64 {
+		switch query.Sort {
+		case "winrate":
+			return e.WinRate()
+		case "games":
+			return float64(e.GamesPlayed())
+		default:
+			return float64(e.LP())
+		}
+	}
+
+	slices.SortStableFunc(filtered, func(a, b T) int {
+		if query.Ascending {
+			return cmp.Compare(key(a), key(b))
+		}
+		return cmp.Compare(key(b), key(a))
+	})
+
+	return paginate(filtered, query.Page, query.PageSize), len(filtered)
+}
+
+// setPagePagination records a page-numbered result in the response meta. The
+// next cursor is the following page number while entries remain. A zero
+// pageSize means everything was returned on one page.
+func setPagePagination(ctx context.Context, page, pageSize, total int) {
+	pagination := Pagination{Page: page, PageSize: pageSize, Total: total}
+	if pageSize > 0 && page*pageSize < total {
+		pagination.NextCursor = strconv.Itoa(page + 1)
+	}
+	SetPagination(ctx, pagination)
+}
+
+// paginate returns the given page of items, or all of them when pageSize is
+// zero.
+func paginate[T any](items []T, page, pageSize int) []T {
+	if pageSize == 0 {
+		return items
+	}
+	start := (page - 1) * pageSize
+	if start >= len(items) {
+		return []T{}
+	}
+	return items[start:min(start+pageSize, len(items))]
+}
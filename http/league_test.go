@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/riot"
+)
+
+func serveApexLeague(t *testing.T, players int, target string) (riot.LeagueList, *Meta) {
+	t.Helper()
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetch := func(ctx context.Context, region string) (*riot.LeagueList, error) {
+		list := &riot.LeagueList{Tier: "CHALLENGER"}
+		for i := range players {
+			list.Entries = append(list.Entries, riot.LeagueItem{LeaguePoints: 1000 + i})
+		}
+		return list, nil
+	}
+
+	rec := httptest.NewRecorder()
+	WithResponseMeta(apexLeague("challenger", fetch, log)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	var body struct {
+		Data riot.LeagueList `json:"data"`
+		Meta *Meta           `json:"meta"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Data, body.Meta
+}
+
+func TestApexLeagueUnpagedByDefault(t *testing.T) {
+	page, meta := serveApexLeague(t, 120, "/league/challenger")
+
+	if len(page.Entries) != 120 {
+		t.Errorf("entries = %d, want the full ladder of 120", len(page.Entries))
+	}
+	if page.Tier != "CHALLENGER" {
+		t.Errorf("tier = %q, want the league fields alongside the entries", page.Tier)
+	}
+	if meta.Pagination == nil || meta.Pagination.Total != 120 || meta.Pagination.NextCursor != "" {
+		t.Errorf("pagination = %+v, want total 120 and no next cursor", meta.Pagination)
+	}
+}
+
+func TestApexLeaguePagedOnRequest(t *testing.T) {
+	for _, target := range []string{"/league/challenger?page=1", "/league/challenger?pageSize=50"} {
+		page, meta := serveApexLeague(t, 120, target)
+
+		if len(page.Entries) != 50 {
+			t.Errorf("%s: entries = %d, want 50", target, len(page.Entries))
+		}
+		if meta.Pagination == nil || meta.Pagination.NextCursor != "2" {
+			t.Errorf("%s: pagination = %+v, want next cursor 2", target, meta.Pagination)
+		}
+	}
+}
+
+func TestRatedLadderPaging(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newFakeRiotClient(t, func(w http.ResponseWriter, r *http.Request) {
+		ladder := make([]riot.RatedLadderEntry, 120)
+		for i := range ladder {
+			ladder[i].RatedRating = 5000 - i
+		}
+		json.NewEncoder(w).Encode(ladder)
+	})
+
+	tests := []struct {
+		target   string
+		want     int
+		wantMeta Pagination
+	}{
+		{"/league/rated-ladder", 120, Pagination{Page: 1, Total: 120}},
+		{"/league/rated-ladder?page=3", 20, Pagination{Page: 3, PageSize: 50, Total: 120}},
+		{"/league/rated-ladder?pageSize=100", 100, Pagination{Page: 1, PageSize: 100, Total: 120, NextCursor: "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WithResponseMeta(ratedLadder(client, log)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+
+			var body struct {
+				Data []riot.RatedLadderEntry `json:"data"`
+				Meta *Meta                   `json:"meta"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(body.Data) != tt.want {
+				t.Errorf("entries = %d, want %d", len(body.Data), tt.want)
+			}
+			if body.Meta == nil || body.Meta.Pagination == nil || *body.Meta.Pagination != tt.wantMeta {
+				t.Errorf("meta = %+v, want pagination %+v", body.Meta, tt.wantMeta)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"github.com/rsdlab-dk/tft-core/riot"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRecentMatches = 5
+	maxRecentMatches     = 20
+	recentMatchWorkers   = 5
+	// riotDefaultMatchCount is what match-v1 returns when count is omitted.
+	riotDefaultMatchCount = 20
+)
+
+// RecentMatchesRateLimitRule is the rule charged for /match/recent, which
+// makes up to maxRecentMatches+1 Riot calls per request and so gets a
+// budget of its own rather than sharing "match".
+const RecentMatchesRateLimitRule = "match-recent"
+
+// RecentMatch is one match seen from a single player: the match summary plus
+// that player's participant entry, without the other seven boards.
+type RecentMatch struct {
+	MatchID      string           `json:"matchId"`
+	GameDatetime int64            `json:"gameDatetime"`
+	GameLength   float64          `json:"gameLength"`
+	GameVersion  string           `json:"gameVersion"`
+	QueueID      int              `json:"queueId"`
+	TftSetNumber int              `json:"tftSetNumber"`
+	Participant  riot.Participant `json:"participant"`
+}
+
+// RecentMatches lists the matches that could be fetched. Matches Riot no
+// longer has, or refused individually, are listed in Failed instead of
+// failing the whole response.
+type RecentMatches struct {
+	Matches []RecentMatch `json:"matches"`
+	Failed  []FailedMatch `json:"failed,omitempty"`
+}
+
+type FailedMatch struct {
+	MatchID string `json:"matchId"`
+	Status  int    `json:"status"`
+	Error   string `json:"error"`
+}
+
+func MatchHistoryHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, "match-list", log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(matchHistory(riotClient, log)).ServeHTTP
+}
+
+func matchHistory(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		puuid := r.URL.Query().Get("puuid")
+		region := queryRegion(r)
+
+		requestID := logger.GetRequestID(r.Context())
+
+		if !ValidatePUUID(puuid, requestID, log, w, r) {
+			return
+		}
+
+		if !ValidateRegion(region, requestID, log, w, r) {
+			return
+		}
+
+		listOpts, ok := ParseMatchListOptions(0, requestID, log, w, r)
+		if !ok {
+			return
+		}
+
+		log.WithContext(r.Context()).Info("match history request",
+			zap.String("puuid", puuid),
+			zap.String("region", region),
+			zap.Int("start", listOpts.Start),
+			zap.Int("count", listOpts.Count))
+
+		result, err := riotClient.GetMatchIDsByPUUID(r.Context(), riot.RegionToMatchCluster(region), puuid, listOpts)
+		if err != nil {
+			handleRiotError(err, log, w, r, requestID)
+			return
+		}
+
+		SetSource(r.Context(), riot.RegionToMatchCluster(region), time.Now())
+
+		// Riot does not report a total; a full page means there may be more.
+		pageSize := listOpts.Count
+		if pageSize == 0 {
+			pageSize = riotDefaultMatchCount
+		}
+		pagination := Pagination{PageSize: pageSize}
+		if len(result) == pageSize {
+			pagination.NextCursor = strconv.Itoa(listOpts.Start + pageSize)
+		}
+		SetPagination(r.Context(), pagination)
+
+		log.WithContext(r.Context()).Info("match history request successful",
+			zap.String("puuid", puuid),
+			zap.Int("matches", len(result)))
+
+		WriteJSON(w, result, log, r)
+	}
+}
+
+func MatchHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, "match", log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(matchByID(riotClient, log)).ServeHTTP
+}
+
+func matchByID(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		matchID := r.URL.Query().Get("matchId")
+		region := queryRegion(r)
+
+		requestID := logger.GetRequestID(r.Context())
+
+		if !ValidateMatchID(matchID, requestID, log, w, r) {
+			return
+		}
+
+		if !ValidateRegion(region, requestID, log, w, r) {
+			return
+		}
+
+		log.WithContext(r.Context()).Info("match request",
+			zap.String("matchId", matchID),
+			zap.String("region", region))
+
+		result, err := riotClient.GetMatch(r.Context(), riot.RegionToMatchCluster(region), matchID)
+		if err != nil {
+			handleRiotError(err, log, w, r, requestID)
+			return
+		}
+
+		SetSource(r.Context(), riot.RegionToMatchCluster(region), time.Now())
+
+		SetLastModified(w, time.UnixMilli(result.Info.GameDatetime))
+
+		log.WithContext(r.Context()).Info("match request successful",
+			zap.String("matchId", matchID),
+			zap.Int("participants", len(result.Info.Participants)))
+
+		WriteJSON(w, result, log, r)
+	}
+}
+
+func RecentMatchesHandler(riotClient *riot.Client, rateLimiter ratelimit.Limiter, log *logger.Logger, opts ...RateLimitOption) http.HandlerFunc {
+	return NewChain(WithCORS, WithResponseMeta, WithRateLimit(rateLimiter, RecentMatchesRateLimitRule, log, opts...), WithTimeout(DefaultRequestTimeout, log)).Then(recentMatches(riotClient, log)).ServeHTTP
+}
+
+func recentMatches(riotClient *riot.Client, log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		puuid := r.URL.Query().Get("puuid")
+		region := queryRegion(r)
+
+		requestID := logger.GetRequestID(r.Context())
+
+		if !ValidatePUUID(puuid, requestID, log, w, r) {
+			return
+		}
+
+		if !ValidateRegion(region, requestID, log, w, r) {
+			return
+		}
+
+		listOpts, ok := ParseMatchListOptions(defaultRecentMatches, requestID, log, w, r)
+		if !ok {
+			return
+		}
+
+		if listOpts.Count > maxRecentMatches {
+			log.WithContext(r.Context()).Warn("recent matches count too large",
+				zap.String("request_id", requestID),
+				zap.Int("count", listOpts.Count))
+			WriteBadRequest(w, "Count must be at most 20 for recent matches", log, r)
+			return
+		}
+
+		log.WithContext(r.Context()).Info("recent matches request",
+			zap.String("puuid", puuid),
+			zap.String("region", region),
+			zap.Int("count", listOpts.Count))
+
+		cluster := riot.RegionToMatchCluster(region)
+		matchIDs, err := riotClient.GetMatchIDsByPUUID(r.Context(), cluster, puuid, listOpts)
+		if err != nil {
+			handleRiotError(err, log, w, r, requestID)
+			return
+		}
+
+		SetSource(r.Context(), cluster, time.Now())
+
+		// The first error that is not isolated to its match fails the
+		// response, so it cancels the calls still queued or in flight
+		// rather than spending Riot budget on them.
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		matches := make([]*riot.Match, len(matchIDs))
+		errs := make([]error, len(matchIDs))
+		sem := make(chan struct{}, recentMatchWorkers)
+		var (
+			wg        sync.WaitGroup
+			fatalOnce sync.Once
+			fatal     error
+		)
+
+		for i, matchID := range matchIDs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				defer func() { <-sem }()
+				if ctx.Err() != nil {
+					return
+				}
+
+				matches[i], errs[i] = riotClient.GetMatch(ctx, cluster, matchID)
+				if errs[i] == nil {
+					return
+				}
+				if _, isolated := isolatedMatchError(errs[i]); !isolated {
+					fatalOnce.Do(func() {
+						fatal = errs[i]
+						cancel()
+					})
+				}
+			}()
+		}
+		wg.Wait()
+
+		if fatal == nil {
+			// A client that went away cancels ctx without any call failing.
+			fatal = r.Context().Err()
+		}
+		if fatal != nil {
+			handleRiotError(fatal, log, w, r, requestID)
+			return
+		}
+
+		result := RecentMatches{Matches: make([]RecentMatch, 0, len(matches))}
+		for i, match := range matches {
+			if errs[i] != nil {
+				riotErr, _ := isolatedMatchError(errs[i])
+				log.WithContext(r.Context()).Warn("skipping match",
+					zap.String("request_id", requestID),
+					zap.String("matchId", matchIDs[i]),
+					zap.Error(errs[i]))
+				result.Failed = append(result.Failed, FailedMatch{
+					MatchID: matchIDs[i],
+					Status:  riotErr.StatusCode,
+					Error:   riotErr.Message,
+				})
+				continue
+			}
+
+			participant, found := match.Participant(puuid)
+			if !found {
+				log.WithContext(r.Context()).Warn("player missing from match participants",
+					zap.String("request_id", requestID),
+					zap.String("matchId", matchIDs[i]))
+				continue
+			}
+
+			result.Matches = append(result.Matches, RecentMatch{
+				MatchID:      match.Metadata.MatchID,
+				GameDatetime: match.Info.GameDatetime,
+				GameLength:   match.Info.GameLength,
+				GameVersion:  match.Info.GameVersion,
+				QueueID:      match.Info.QueueID,
+				TftSetNumber: match.Info.TftSetNumber,
+				Participant:  *participant,
+			})
+		}
+
+		log.WithContext(r.Context()).Info("recent matches request successful",
+			zap.String("puuid", puuid),
+			zap.Int("matches", len(result.Matches)),
+			zap.Int("failed", len(result.Failed)))
+
+		WriteJSON(w, result, log, r)
+	}
+}
+
+// isolatedMatchError reports whether err concerns only the one match, such as
+// a 404 for a match Riot has expired. Rate limits, key problems, 5xx, an open
+// circuit or a cancelled request would fail the other calls too, so they fail
+// the response.
+func isolatedMatchError(err error) (*riot.RiotError, bool) {
+	var riotErr *riot.RiotError
+	if !errors.As(err, &riotErr) {
+		return nil, false
+	}
+	isolated := riotErr.StatusCode >= 400 && riotErr.StatusCode < 500 &&
+		!riotErr.IsRateLimited() && !riotErr.IsUnauthorized() && !riotErr.IsForbidden()
+	return riotErr, isolated
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/riot"
+)
+
+// redirectTransport sends every Riot call to server, whatever the host.
+type redirectTransport struct {
+	server *httptest.Server
+}
+
+func (t redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(t.server.URL)
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host = target.Scheme, target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func newFakeRiotClient(t *testing.T, handler http.HandlerFunc) *riot.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return riot.NewClient("test-key", riot.WithHTTPClient(&http.Client{Transport: redirectTransport{server}}))
+}
+
+func serveRecentMatches(t *testing.T, client *riot.Client, puuid string) (*httptest.ResponseRecorder, Response) {
+	t.Helper()
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	recentMatches(client, log)(rec, httptest.NewRequest(http.MethodGet, "/match/recent?count=3&puuid="+puuid, nil))
+
+	var body Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return rec, body
+}
+
+func TestRecentMatchesReportsFailedMatches(t *testing.T) {
+	puuid := strings.Repeat("p", 78)
+	client := newFakeRiotClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/ids"):
+			json.NewEncoder(w).Encode([]string{"BR1_1", "BR1_2", "BR1_3"})
+		case strings.HasSuffix(r.URL.Path, "/BR1_2"):
+			http.Error(w, `{"status":{"message":"Data not found"}}`, http.StatusNotFound)
+		default:
+			id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			json.NewEncoder(w).Encode(riot.Match{
+				Metadata: riot.MatchMetadata{MatchID: id},
+				Info:     riot.MatchInfo{Participants: []riot.Participant{{PUUID: puuid, Placement: 1}}},
+			})
+		}
+	})
+
+	rec, body := serveRecentMatches(t, client, puuid)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	data, _ := json.Marshal(body.Data)
+	var result RecentMatches
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Matches) != 2 || result.Matches[0].MatchID != "BR1_1" || result.Matches[1].MatchID != "BR1_3" {
+		t.Errorf("matches = %+v, want BR1_1 and BR1_3", result.Matches)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].MatchID != "BR1_2" || result.Failed[0].Status != http.StatusNotFound {
+		t.Errorf("failed = %+v, want BR1_2 with 404", result.Failed)
+	}
+}
+
+func TestRecentMatchesFailsOnSystemicError(t *testing.T) {
+	puuid := strings.Repeat("p", 78)
+	client := newFakeRiotClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/ids") {
+			json.NewEncoder(w).Encode([]string{"BR1_1"})
+			return
+		}
+		http.Error(w, `{"status":{"message":"Forbidden"}}`, http.StatusForbidden)
+	})
+
+	rec, body := serveRecentMatches(t, client, puuid)
+	if rec.Code != http.StatusUnauthorized || body.Error == nil || body.Error.Code != "API_KEY_ERROR" {
+		t.Errorf("status = %d, error = %+v; want 401 API_KEY_ERROR", rec.Code, body.Error)
+	}
+}
+
+func TestRecentMatchesUppercaseRegion(t *testing.T) {
+	puuid := strings.Repeat("p", 78)
+	var hosts []string
+	client := newFakeRiotClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		json.NewEncoder(w).Encode([]string{})
+	})
+
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler := WithResponseMeta(recentMatches(client, log))
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/match/recent?region=EUW1&puuid="+puuid, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	if len(hosts) != 1 || hosts[0] != "europe.api.riotgames.com" {
+		t.Errorf("riot hosts = %v, want europe.api.riotgames.com", hosts)
+	}
+
+	var body Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Meta == nil || body.Meta.Region != "europe" {
+		t.Errorf("meta = %+v, want region europe", body.Meta)
+	}
+}
+
+func TestRecentMatchesCancelsAfterFatalError(t *testing.T) {
+	puuid := strings.Repeat("p", 78)
+	var calls atomic.Int32
+	client := newFakeRiotClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/ids") {
+			json.NewEncoder(w).Encode([]string{"BR1_1", "BR1_2", "BR1_3", "BR1_4", "BR1_5", "BR1_6", "BR1_7", "BR1_8"})
+			return
+		}
+
+		calls.Add(1)
+		if strings.HasSuffix(r.URL.Path, "/BR1_1") {
+			http.Error(w, `{"status":{"message":"Rate limit exceeded"}}`, http.StatusTooManyRequests)
+			return
+		}
+		// The other matches only answer once their call is cancelled.
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+			json.NewEncoder(w).Encode(riot.Match{})
+		}
+	})
+
+	start := time.Now()
+	rec, body := serveRecentMatches(t, client, puuid)
+	if rec.Code != http.StatusTooManyRequests || body.Error == nil || body.Error.Code != "RATE_LIMITED" {
+		t.Errorf("status = %d, error = %+v; want 429 RATE_LIMITED", rec.Code, body.Error)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %s, want the pending calls cancelled", elapsed)
+	}
+	if got := calls.Load(); got > recentMatchWorkers {
+		t.Errorf("match calls = %d, want at most %d", got, recentMatchWorkers)
+	}
+}
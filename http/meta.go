@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const metaKey contextKey = "response_meta"
+
+const (
+	CacheHit   = "HIT"
+	CacheMiss  = "MISS"
+	CacheStale = "STALE"
+)
+
+// Meta describes where a response came from. Handlers and middleware fill it
+// in through SetPagination, SetCacheStatus and SetSource; WriteJSON and
+// WriteError add the request ID.
+type Meta struct {
+	RequestID  string      `json:"request_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	Cache      string      `json:"cache,omitempty"`
+	FetchedAt  *time.Time  `json:"fetched_at,omitempty"`
+	Region     string      `json:"region,omitempty"`
+}
+
+// Pagination is zero-valued where unknown: cursor-based lists such as match
+// history have no page number or total. It is the only place paged endpoints
+// report pagination; their payload is the bare list.
+type Pagination struct {
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"page_size,omitempty"`
+	Total      int    `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// metaHolder is shared by every layer serving one request, some of which run
+// on their own goroutines.
+type metaHolder struct {
+	mu   sync.Mutex
+	meta Meta
+}
+
+// WithResponseMeta enables the meta block for the request. Without it the
+// setters do nothing and responses carry no meta.
+func WithResponseMeta(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), metaKey, &metaHolder{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func SetPagination(ctx context.Context, pagination Pagination) {
+	updateMeta(ctx, func(m *Meta) {
+		m.Pagination = &pagination
+	})
+}
+
+func SetCacheStatus(ctx context.Context, status string) {
+	updateMeta(ctx, func(m *Meta) {
+		m.Cache = status
+	})
+}
+
+// SetSource records the Riot region or cluster that served the data and when
+// it was fetched.
+func SetSource(ctx context.Context, region string, fetchedAt time.Time) {
+	updateMeta(ctx, func(m *Meta) {
+		m.Region = region
+		m.FetchedAt = &fetchedAt
+	})
+}
+
+// MetaFromContext returns a copy of the request's meta, or nil when
+// WithResponseMeta is not in the chain.
+func MetaFromContext(ctx context.Context) *Meta {
+	holder, ok := ctx.Value(metaKey).(*metaHolder)
+	if !ok {
+		return nil
+	}
+
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+
+	meta := holder.meta
+	return &meta
+}
+
+func updateMeta(ctx context.Context, update func(*Meta)) {
+	holder, ok := ctx.Value(metaKey).(*metaHolder)
+	if !ok {
+		return
+	}
+
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+
+	update(&holder.meta)
+}
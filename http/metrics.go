@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/metrics"
+)
+
+const routeKey contextKey = "route"
+
+// unmatchedRoute labels requests that matched no route, so scans of random
+// paths cannot grow the label set.
+const unmatchedRoute = "unmatched"
+
+// routeHolder lets Router report the matched pattern back to WithMetrics,
+// which runs before routing.
+type routeHolder struct {
+	pattern string
+}
+
+// WithMetrics records the count, latency and status of every request under
+// the route pattern that served it. Conditional requests (If-None-Match or
+// If-Modified-Since) on GET and HEAD also count as revalidation hits when
+// answered with 304 and misses when the full body was sent.
+func WithMetrics(m *metrics.Metrics) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := m.RequestStarted()
+			defer done()
+
+			start := time.Now()
+			route := &routeHolder{pattern: unmatchedRoute}
+			ctx := context.WithValue(r.Context(), routeKey, route)
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+			m.ObserveRequest(route.pattern, metricMethod(r.Method), wrapped.statusCode, time.Since(start))
+
+			if conditional(r) && (wrapped.statusCode == http.StatusOK || wrapped.statusCode == http.StatusNotModified) {
+				m.ObserveRevalidation(route.pattern, wrapped.statusCode == http.StatusNotModified)
+			}
+		})
+	}
+}
+
+func conditional(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	return r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != ""
+}
+
+// recordRoute tells WithMetrics which route pattern matched.
+func recordRoute(r *http.Request, pattern string) {
+	if route, ok := r.Context().Value(routeKey).(*routeHolder); ok {
+		route.pattern = pattern
+	}
+}
+
+// metricMethod folds nonstandard methods into "OTHER" to keep the method
+// label bounded.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/metrics"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+)
+
+func newTestMetrics(t *testing.T) (*metrics.Metrics, *prometheus.Registry) {
+	t.Helper()
+
+	registry := prometheus.NewRegistry()
+	m, err := metrics.New(metrics.Config{Registry: registry})
+	if err != nil {
+		t.Fatalf("metrics.New: %v", err)
+	}
+	return m, registry
+}
+
+func TestWithMetricsRequests(t *testing.T) {
+	m, registry := newTestMetrics(t)
+
+	router := NewRouter(WithMetrics(m))
+	router.HandleFunc("GET /match", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("matchId") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+
+	for _, target := range []string{"/match?matchId=BR1_1", "/match?matchId=BR1_2", "/match", "/nope", "/nope/again"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PURGE", "/match", nil))
+
+	want := `
+# HELP tft_http_requests_total Inbound requests by route, method and status code.
+# TYPE tft_http_requests_total counter
+tft_http_requests_total{method="GET",route="GET /match",status="200"} 2
+tft_http_requests_total{method="GET",route="GET /match",status="400"} 1
+tft_http_requests_total{method="GET",route="unmatched",status="404"} 2
+tft_http_requests_total{method="OTHER",route="unmatched",status="405"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "tft_http_requests_total"); err != nil {
+		t.Error(err)
+	}
+
+	if got := testutil.CollectAndCount(registry, "tft_http_request_duration_seconds"); got != 4 {
+		t.Errorf("duration series = %d, want 4", got)
+	}
+
+	inFlight := `
+# HELP tft_http_requests_in_flight Inbound requests currently being served.
+# TYPE tft_http_requests_in_flight gauge
+tft_http_requests_in_flight 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(inFlight), "tft_http_requests_in_flight"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithMetricsRevalidations(t *testing.T) {
+	m, registry := newTestMetrics(t)
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter(WithMetrics(m))
+	router.HandleFunc("GET /match", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, map[string]string{"matchId": "BR1_1"}, log, r)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/match", nil))
+	etag := rec.Header().Get("ETag")
+
+	for _, inm := range []string{etag, etag, `"stale"`} {
+		req := httptest.NewRequest(http.MethodGet, "/match", nil)
+		req.Header.Set("If-None-Match", inm)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := `
+# HELP tft_http_revalidations_total Conditional requests by route and result: hit when the client's copy was still fresh (304), miss otherwise.
+# TYPE tft_http_revalidations_total counter
+tft_http_revalidations_total{result="hit",route="GET /match"} 2
+tft_http_revalidations_total{result="miss",route="GET /match"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "tft_http_revalidations_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRateLimitRecorderMetrics(t *testing.T) {
+	m, registry := newTestMetrics(t)
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.WithCleanupInterval(0))
+	rules := &ratelimit.Config{Default: ratelimit.NewRule(2, time.Minute)}
+
+	router := NewRouter()
+	router.HandleFunc("GET /league/challenger", func(w http.ResponseWriter, r *http.Request) {},
+		WithRateLimit(limiter, "league", log, RateLimitRules(rules), RateLimitRecorder(m)))
+
+	for range 5 {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/league/challenger", nil))
+	}
+
+	want := `
+# HELP tft_ratelimit_decisions_total Rate limit decisions by rule, key class and result: allowed or denied.
+# TYPE tft_ratelimit_decisions_total counter
+tft_ratelimit_decisions_total{class="ip",result="allowed",rule="league"} 2
+tft_ratelimit_decisions_total{class="ip",result="denied",rule="league"} 3
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "tft_ratelimit_decisions_total"); err != nil {
+		t.Error(err)
+	}
+}
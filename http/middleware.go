@@ -3,34 +3,205 @@ package http
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/rsdlab-dk/tft-core/logger"
 	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
-func WithRequestID(log *logger.Logger) func(http.HandlerFunc) http.HandlerFunc {
-	return func(next http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			requestID := logger.GenerateRequestID()
-			ctx := logger.WithRequestID(r.Context(), requestID)
+const (
+	DefaultRequestIDHeader    = "X-Request-ID"
+	DefaultRequestIDMaxLength = 128
+	// DefaultRequestIDCharset is allowed in incoming IDs on top of ASCII
+	// letters and digits.
+	DefaultRequestIDCharset = "-_.:"
+	maxTraceStateLength     = 512
+)
+
+type RequestIDConfig struct {
+	// Header carries the ID in both directions. Empty means
+	// DefaultRequestIDHeader.
+	Header string
+	// MaxLength bounds accepted IDs. Zero means DefaultRequestIDMaxLength.
+	MaxLength int
+	// Charset lists the characters accepted besides ASCII letters and
+	// digits. Empty means DefaultRequestIDCharset.
+	Charset string
+	// IgnoreIncoming always generates a new ID, for servers exposed
+	// directly rather than behind a gateway that assigns them.
+	IgnoreIncoming bool
+}
+
+func DefaultRequestIDConfig() RequestIDConfig {
+	return RequestIDConfig{
+		Header:    DefaultRequestIDHeader,
+		MaxLength: DefaultRequestIDMaxLength,
+		Charset:   DefaultRequestIDCharset,
+	}
+}
 
-			w.Header().Set("X-Request-ID", requestID)
+// WithRequestID applies DefaultRequestIDConfig.
+func WithRequestID(log *logger.Logger) Middleware {
+	return WithRequestIDConfig(DefaultRequestIDConfig(), log)
+}
+
+// WithRequestIDConfig keeps a valid incoming request ID or generates a new
+// one, echoes it in the response and stores it in the context. A valid W3C
+// traceparent, with its tracestate, is stored as well so logs and Riot calls
+// stay on the caller's trace.
+func WithRequestIDConfig(config RequestIDConfig, log *logger.Logger) Middleware {
+	if config.Header == "" {
+		config.Header = DefaultRequestIDHeader
+	}
+	if config.MaxLength <= 0 {
+		config.MaxLength = DefaultRequestIDMaxLength
+	}
+	if config.Charset == "" {
+		config.Charset = DefaultRequestIDCharset
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			if traceParent := r.Header.Get("traceparent"); traceParent != "" {
+				tc, err := logger.ParseTraceParent(traceParent)
+				if err == nil {
+					if state := r.Header.Get("tracestate"); len(state) <= maxTraceStateLength {
+						tc.State = state
+					}
+					ctx = logger.WithTraceContext(ctx, tc)
+				} else {
+					log.Debug("ignoring invalid traceparent",
+						zap.String("traceparent", traceParent))
+				}
+			}
+
+			var requestID string
+			if !config.IgnoreIncoming {
+				requestID = r.Header.Get(config.Header)
+				if requestID != "" && !validRequestID(requestID, config) {
+					log.WithContext(ctx).Warn("replacing invalid request id",
+						zap.Int("length", len(requestID)))
+					requestID = ""
+				}
+			}
+			if requestID == "" {
+				requestID = logger.GenerateRequestID()
+			}
+
+			ctx = logger.WithRequestID(ctx, requestID)
+			w.Header().Set(config.Header, requestID)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func validRequestID(requestID string, config RequestIDConfig) bool {
+	if len(requestID) > config.MaxLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlnum && !strings.ContainsRune(config.Charset, c) {
+			return false
 		}
 	}
+	return true
+}
+
+type RateLimitOption func(*rateLimitOptions)
+
+type rateLimitOptions struct {
+	rules     ratelimit.RuleSource
+	key       KeyFunc
+	plans     ratelimit.PlanLookup
+	stats     *ratelimit.Stats
+	recorders []ratelimit.Recorder
 }
 
-func WithRateLimit(limiter ratelimit.Limiter, endpoint string, log *logger.Logger) func(http.HandlerFunc) http.HandlerFunc {
-	return func(next http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			config := ratelimit.NewConfig()
-			rule := config.GetRule(endpoint)
+var defaultRateLimitConfig = ratelimit.NewConfig()
+
+func RateLimitRules(rules ratelimit.RuleSource) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.rules = rules
+	}
+}
+
+func RateLimitKey(key KeyFunc) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.key = key
+	}
+}
+
+// RateLimitPlans resolves the plan of each client from its rate limit key.
+// Both *ratelimit.Config and *ratelimit.ConfigStore implement PlanLookup.
+func RateLimitPlans(plans ratelimit.PlanLookup) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.plans = plans
+	}
+}
+
+func RateLimitStats(stats *ratelimit.Stats) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.stats = stats
+	}
+}
 
-			key := endpoint + ":" + r.RemoteAddr
-			allowed, err := limiter.Allow(r.Context(), key, rule.Rate, rule.Window)
+// RateLimitRecorder reports every decision to recorder, such as a
+// *metrics.Metrics. It may be given more than once.
+func RateLimitRecorder(recorder ratelimit.Recorder) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.recorders = append(o.recorders, recorder)
+	}
+}
+
+func WithRateLimit(limiter ratelimit.Limiter, endpoint string, log *logger.Logger, opts ...RateLimitOption) Middleware {
+	options := rateLimitOptions{
+		rules: defaultRateLimitConfig,
+		key:   ClientIPKey(ClientIPConfig{}),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientKey, err := options.key(r)
+			if err != nil {
+				log.WithContext(r.Context()).Warn("rate limit key unavailable",
+					zap.String("endpoint", endpoint),
+					zap.Error(err))
+				WriteBadRequest(w, "Unable to identify client", log, r)
+				return
+			}
+
+			var plan string
+			if options.plans != nil {
+				plan, err = options.plans.LookupPlan(r.Context(), clientKey)
+				if err != nil {
+					log.WithContext(r.Context()).Error("rate limit plan lookup error",
+						zap.String("endpoint", endpoint),
+						zap.Error(err))
+					WriteInternalError(w, log, r)
+					return
+				}
+			}
+
+			rule, limited := options.rules.GetPlanRule(plan, endpoint)
+			if !limited {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := endpoint + ":" + clientKey
+			allowed, err := limiter.Allow(r.Context(), key, rule)
 			if err != nil {
 				log.WithContext(r.Context()).Error("rate limiter error",
 					zap.String("endpoint", endpoint),
@@ -39,22 +210,50 @@ func WithRateLimit(limiter ratelimit.Limiter, endpoint string, log *logger.Logge
 				return
 			}
 
+			if options.stats != nil {
+				options.stats.Record(endpoint, ratelimit.KeyClass(clientKey), allowed)
+			}
+			for _, recorder := range options.recorders {
+				recorder.Record(endpoint, ratelimit.KeyClass(clientKey), allowed)
+			}
+
+			trace.SpanFromContext(r.Context()).AddEvent("ratelimit.decision", trace.WithAttributes(
+				attribute.String("ratelimit.endpoint", endpoint),
+				attribute.String("ratelimit.key_class", ratelimit.KeyClass(clientKey)),
+				attribute.String("ratelimit.plan", plan),
+				attribute.String("ratelimit.rule", rule.String()),
+				attribute.Bool("ratelimit.allowed", allowed),
+			))
+
 			if !allowed {
 				log.WithContext(r.Context()).Warn("rate limit exceeded",
 					zap.String("endpoint", endpoint),
-					zap.String("key", key))
+					zap.String("key", key),
+					zap.String("plan", plan))
+				setRetryAfter(w, retryAfter(r.Context(), limiter, key, rule))
 				WriteError(w, "RATE_LIMIT_EXCEEDED", "Rate limit exceeded", http.StatusTooManyRequests, log, r)
 				return
 			}
 
 			next.ServeHTTP(w, r)
-		}
+		})
 	}
 }
 
-func WithLogging(log *logger.Logger) func(http.HandlerFunc) http.HandlerFunc {
-	return func(next http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
+// retryAfter asks the limiter when key could next be served by reserving a
+// slot and handing it straight back. Zero means unknown.
+func retryAfter(ctx context.Context, limiter ratelimit.Limiter, key string, rule ratelimit.Rule) time.Duration {
+	reservation, err := limiter.Reserve(ctx, key, rule)
+	if err != nil {
+		return 0
+	}
+	defer reservation.Cancel()
+	return reservation.Delay()
+}
+
+func WithLogging(log *logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 
 			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
@@ -70,31 +269,63 @@ func WithLogging(log *logger.Logger) func(http.HandlerFunc) http.HandlerFunc {
 				zap.Duration("duration", duration),
 				zap.String("user_agent", r.UserAgent()),
 			)
-		}
+		})
 	}
 }
 
-func WithCORS(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID")
+func WithRecovery(log *logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
 
-		next.ServeHTTP(w, r)
+				// ErrAbortHandler is the documented way to abort a response
+				// and must reach net/http untouched.
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				log.WithContext(r.Context()).Error("panic recovered",
+					zap.Any("panic", recovered),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Stack("stack"))
+
+				if wrapped.wroteHeader {
+					return
+				}
+				WriteInternalError(wrapped, log, r)
+			}()
+
+			next.ServeHTTP(wrapped, r)
+		})
 	}
 }
 
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	wroteHeader bool
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
+	if !rw.wroteHeader {
+		rw.statusCode = code
+		rw.wroteHeader = true
+	}
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
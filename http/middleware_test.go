@@ -0,0 +1,117 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+)
+
+// serveRequestID runs a request with headers through WithRequestIDConfig and
+// returns the response along with the context values the handler saw.
+func serveRequestID(t *testing.T, config RequestIDConfig, headers map[string]string) (*httptest.ResponseRecorder, string, logger.TraceContext, bool) {
+	t.Helper()
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		requestID string
+		tc        logger.TraceContext
+		traced    bool
+	)
+	handler := WithRequestIDConfig(config, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = logger.GetRequestID(r.Context())
+		tc, traced = logger.GetTraceContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for key, value := range headers {
+		r.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	return rec, requestID, tc, traced
+}
+
+func TestWithRequestIDIncoming(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RequestIDConfig
+		header string
+		id     string
+		keep   bool
+	}{
+		{"valid", RequestIDConfig{}, "X-Request-ID", "req-123_abc.def:1", true},
+		{"uuid", RequestIDConfig{}, "X-Request-ID", "3f2504e0-4f89-11d3-9a0c-0305e82c3301", true},
+		{"custom header", RequestIDConfig{Header: "X-Correlation-ID"}, "X-Correlation-ID", "corr-1", true},
+		{"missing", RequestIDConfig{}, "X-Request-ID", "", false},
+		{"too long", RequestIDConfig{}, "X-Request-ID", strings.Repeat("a", DefaultRequestIDMaxLength+1), false},
+		{"custom max length", RequestIDConfig{MaxLength: 4}, "X-Request-ID", "abcde", false},
+		{"space", RequestIDConfig{}, "X-Request-ID", "req 1", false},
+		{"log injection", RequestIDConfig{}, "X-Request-ID", "req\"}\n{\"level\":\"error", false},
+		{"non-ascii", RequestIDConfig{}, "X-Request-ID", "réq", false},
+		{"outside custom charset", RequestIDConfig{Charset: "_"}, "X-Request-ID", "req-1", false},
+		{"ignored", RequestIDConfig{IgnoreIncoming: true}, "X-Request-ID", "req-1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, requestID, _, _ := serveRequestID(t, tt.config, map[string]string{tt.header: tt.id})
+
+			if tt.keep && requestID != tt.id {
+				t.Errorf("request id = %q, want the incoming %q", requestID, tt.id)
+			}
+			if !tt.keep && (requestID == "" || requestID == tt.id) {
+				t.Errorf("request id = %q, want a generated one", requestID)
+			}
+			if got := rec.Header().Get(tt.header); got != requestID {
+				t.Errorf("%s response header = %q, want %q", tt.header, got, requestID)
+			}
+		})
+	}
+}
+
+func TestWithRequestIDTraceContext(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name        string
+		traceParent string
+		traceState  string
+		wantTraced  bool
+		wantState   string
+	}{
+		{"valid", valid, "", true, ""},
+		{"valid with state", valid, "vendor=abc", true, "vendor=abc"},
+		{"oversized state dropped", valid, strings.Repeat("a", maxTraceStateLength+1), true, ""},
+		{"malformed", "not-a-traceparent", "vendor=abc", false, ""},
+		{"all-zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", false, ""},
+		{"all-zero parent id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "", false, ""},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{"traceparent": tt.traceParent}
+			if tt.traceState != "" {
+				headers["tracestate"] = tt.traceState
+			}
+
+			_, requestID, tc, traced := serveRequestID(t, RequestIDConfig{}, headers)
+
+			if traced != tt.wantTraced {
+				t.Fatalf("trace context stored = %v, want %v", traced, tt.wantTraced)
+			}
+			if traced && (tc.TraceParent() != valid || tc.State != tt.wantState) {
+				t.Errorf("trace context = %+v, want %s with state %q", tc, valid, tt.wantState)
+			}
+			if requestID == "" {
+				t.Error("request id missing")
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const problemContentType = "application/problem+json"
+
+// ResponseFormat selects how error responses are written. Successful
+// responses always use the Response envelope.
+type ResponseFormat int
+
+const (
+	// FormatEnvelope writes {"success":false,"error":{"code":...,"message":...}}.
+	FormatEnvelope ResponseFormat = iota
+	// FormatProblem writes RFC 9457 application/problem+json documents.
+	FormatProblem
+)
+
+func (f ResponseFormat) String() string {
+	switch f {
+	case FormatProblem:
+		return "problem"
+	default:
+		return "envelope"
+	}
+}
+
+type FormatConfig struct {
+	Default ResponseFormat
+	// Negotiate lets clients that list application/problem+json in Accept
+	// get problem documents regardless of Default.
+	Negotiate bool
+	// ProblemTypeBase is joined with the lower-cased error code to build the
+	// problem type URI, e.g. "https://api.example.com/problems/" gives
+	// ".../problems/rate_limit_exceeded". Empty means "about:blank".
+	ProblemTypeBase string
+}
+
+// Problem is an RFC 9457 problem details document. Code and RetryAfter are
+// extension members; Instance carries the request ID.
+type Problem struct {
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Status     int    `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Instance   string `json:"instance,omitempty"`
+	Code       string `json:"code"`
+	RetryAfter int    `json:"retry_after,omitempty"`
+}
+
+func WithResponseFormat(config FormatConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			format := config.Default
+			if config.Negotiate {
+				w.Header().Add("Vary", "Accept")
+				if acceptsProblem(r.Header.Get("Accept")) {
+					format = FormatProblem
+				}
+			}
+
+			ctx := ContextWithResponseFormat(r.Context(), format)
+			if config.ProblemTypeBase != "" {
+				ctx = context.WithValue(ctx, problemTypeBaseKey, config.ProblemTypeBase)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// NewProblem builds the problem document for an error response. RetryAfter
+// is taken from a Retry-After header already set in seconds.
+func NewProblem(ctx context.Context, header http.Header, code, message string, statusCode int, requestID string) Problem {
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   message,
+		Instance: requestID,
+		Code:     code,
+	}
+
+	if base := problemTypeBaseFromContext(ctx); base != "" {
+		problem.Type = base + strings.ToLower(code)
+	}
+
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+		problem.RetryAfter = seconds
+	}
+
+	return problem
+}
+
+// acceptsProblem reports whether the Accept header lists problem+json with a
+// non-zero quality. Wildcards do not count: they are satisfied by either format.
+func acceptsProblem(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != problemContentType {
+			continue
+		}
+
+		if q, ok := params["q"]; ok {
+			if value, err := strconv.ParseFloat(q, 64); err != nil || value <= 0 {
+				continue
+			}
+		}
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"github.com/rsdlab-dk/tft-core/riot"
+)
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	var problem Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return problem
+}
+
+func TestRateLimitDenialProblemRetryAfter(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := &ratelimit.Config{Default: ratelimit.NewRule(1, time.Minute)}
+	handler := NewChain(
+		WithResponseFormat(FormatConfig{Default: FormatProblem}),
+		WithRateLimit(ratelimit.NewMemoryLimiter(ratelimit.WithCleanupInterval(0)), "summoner", log, RateLimitRules(rules)),
+	).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var rec *httptest.ResponseRecorder
+	for range 2 {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+	if problem := decodeProblem(t, rec); problem.Code != "RATE_LIMIT_EXCEEDED" || problem.RetryAfter != 60 {
+		t.Errorf("problem = %+v, want RATE_LIMIT_EXCEEDED with retry_after 60", problem)
+	}
+}
+
+func TestRiotRateLimitProblemRetryAfter(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := WithResponseFormat(FormatConfig{Default: FormatProblem})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := &riot.RiotError{StatusCode: http.StatusTooManyRequests, Message: "Rate limit exceeded", RetryAfter: 7 * time.Second}
+		handleRiotError(err, log, w, r, "")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "7" {
+		t.Errorf("Retry-After = %q, want 7", got)
+	}
+	if problem := decodeProblem(t, rec); problem.Code != "RATE_LIMITED" || problem.RetryAfter != 7 {
+		t.Errorf("problem = %+v, want RATE_LIMITED with retry_after 7", problem)
+	}
+}
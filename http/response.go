@@ -12,6 +12,7 @@ type Response struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   *ErrorInfo  `json:"error,omitempty"`
+	Meta    *Meta       `json:"meta,omitempty"`
 }
 
 type ErrorInfo struct {
@@ -19,23 +20,78 @@ type ErrorInfo struct {
 	Message string `json:"message"`
 }
 
+// WriteJSON writes data in the success envelope. GET and HEAD responses get a
+// strong ETag and the Cache-Control set by WithCacheControl; a matching
+// conditional request is answered with 304 and no body. The tag covers the
+// whole body except the meta fields that change on every request, so a new
+// total or page changes it while a new request ID does not.
 func WriteJSON(w http.ResponseWriter, data interface{}, log *logger.Logger, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.WithContext(r.Context()).Error("failed to encode json response",
+			zap.Error(err))
+		WriteError(w, "ENCODING_ERROR", "Failed to encode response", http.StatusInternalServerError, log, r)
+		return
+	}
 
 	response := Response{
 		Success: true,
-		Data:    data,
+		Data:    json.RawMessage(payload),
+		Meta:    responseMeta(r),
 	}
 
+	header := w.Header()
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		header.Set("ETag", entityTag(response))
+		if directive := cacheControlFromContext(r.Context()); directive != "" {
+			header.Set("Cache-Control", directive)
+		}
+
+		if notModified(r, header) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	header.Set("Content-Type", "application/json")
+
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.WithContext(r.Context()).Error("failed to encode json response",
 			zap.Error(err))
-		WriteError(w, "ENCODING_ERROR", "Failed to encode response", http.StatusInternalServerError, log, r)
-		return
 	}
 }
 
+// entityTag hashes response without its request ID, fetch time and cache
+// status, which differ between requests for the same representation.
+func entityTag(response Response) string {
+	if response.Meta != nil {
+		meta := *response.Meta
+		meta.RequestID, meta.FetchedAt, meta.Cache = "", nil, ""
+		response.Meta = &meta
+	}
+
+	// Data is already encoded and Meta holds plain values, so this cannot
+	// fail.
+	body, _ := json.Marshal(response)
+	return ETag(body)
+}
+
+// WriteError writes the error in the format chosen for the request, either
+// the Response envelope or an RFC 9457 problem document.
 func WriteError(w http.ResponseWriter, code, message string, statusCode int, log *logger.Logger, r *http.Request) {
+	if ResponseFormatFromContext(r.Context()) == FormatProblem {
+		problem := NewProblem(r.Context(), w.Header(), code, message, statusCode, logger.GetRequestID(r.Context()))
+
+		w.Header().Set("Content-Type", problemContentType)
+		w.WriteHeader(statusCode)
+
+		if err := json.NewEncoder(w).Encode(problem); err != nil {
+			log.WithContext(r.Context()).Error("failed to encode problem response",
+				zap.Error(err))
+		}
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
@@ -45,6 +101,7 @@ func WriteError(w http.ResponseWriter, code, message string, statusCode int, log
 			Code:    code,
 			Message: message,
 		},
+		Meta: responseMeta(r),
 	}
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -64,3 +121,11 @@ func WriteBadRequest(w http.ResponseWriter, message string, log *logger.Logger,
 func WriteInternalError(w http.ResponseWriter, log *logger.Logger, r *http.Request) {
 	WriteError(w, "INTERNAL_ERROR", "Internal server error", http.StatusInternalServerError, log, r)
 }
+
+func responseMeta(r *http.Request) *Meta {
+	meta := MetaFromContext(r.Context())
+	if meta != nil {
+		meta.RequestID = logger.GetRequestID(r.Context())
+	}
+	return meta
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+)
+
+func TestWriteJSONStrongETag(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter(WithRequestID(log), WithResponseMeta, WithCompression(CompressionConfig{MinSize: 1}))
+	router.HandleFunc("GET /league/challenger", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, map[string]string{"tier": "CHALLENGER"}, log, r)
+	})
+
+	get := func(acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/league/challenger", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first, second := get("", ""), get("", "")
+	if first.Body.String() == second.Body.String() {
+		t.Fatal("bodies should differ by request ID")
+	}
+
+	etag := first.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("ETag = %q, want a strong tag", etag)
+	}
+	if got := second.Header().Get("ETag"); got != etag {
+		t.Errorf("ETag = %q on the second request, want %q", got, etag)
+	}
+
+	if rec := get("", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d with If-None-Match, want 304", rec.Code)
+	}
+
+	gzipped := get("gzip", "")
+	gzipETag := gzipped.Header().Get("ETag")
+	if want := strings.TrimSuffix(etag, `"`) + `-gzip"`; gzipETag != want {
+		t.Errorf("compressed ETag = %q, want %q", gzipETag, want)
+	}
+	if rec := get("gzip", gzipETag); rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d with compressed If-None-Match, want 304", rec.Code)
+	}
+}
+
+func TestWriteJSONETagCoversMeta(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 120
+	router := NewRouter(WithRequestID(log), WithResponseMeta)
+	router.HandleFunc("GET /league/challenger", func(w http.ResponseWriter, r *http.Request) {
+		SetPagination(r.Context(), Pagination{Page: 1, Total: total})
+		WriteJSON(w, map[string]string{"tier": "CHALLENGER"}, log, r)
+	})
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/league/challenger", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	etag := get("").Header().Get("ETag")
+	total = 121
+
+	rec := get(etag)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d after the total changed, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("ETag"); got == etag {
+		t.Errorf("ETag = %q unchanged after the total changed", got)
+	}
+}
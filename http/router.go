@@ -0,0 +1,236 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/metrics"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"github.com/rsdlab-dk/tft-core/riot"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Router mounts handlers on a ServeMux behind one shared middleware chain.
+// The chain wraps the mux itself, so requests that match no route (404, 405,
+// CORS preflight) still get request IDs, logging and CORS headers.
+type Router struct {
+	mux     *http.ServeMux
+	handler http.Handler
+}
+
+type ServerConfig struct {
+	Addr             string
+	RiotClient       *riot.Client
+	RateLimiter      ratelimit.Limiter
+	Logger           *logger.Logger
+	RateLimitOptions []RateLimitOption
+	// Timeout bounds each TFT route. Zero means DefaultRequestTimeout.
+	Timeout time.Duration
+	// ResponseFormat selects the error format. The zero value keeps the
+	// Response envelope without Accept negotiation.
+	ResponseFormat FormatConfig
+	// CacheControl overrides DefaultCacheControl by route pattern. An empty
+	// directive sends no Cache-Control for that route.
+	CacheControl map[string]string
+	// Compression enables WithCompression. Nil leaves responses uncompressed,
+	// e.g. when a proxy in front already compresses.
+	Compression *CompressionConfig
+	// CORS replaces DefaultCORSConfig for every route.
+	CORS *CORSConfig
+	// Auth protects every TFT route. Each request first passes the
+	// AuthRateLimitRule per client IP, so guessing credentials is throttled,
+	// then rate limits key on the principal, falling back to the client IP
+	// for anonymous requests, and follow its plan; RateLimitOptions can
+	// still override both.
+	Auth *AuthConfig
+	// ClientIP identifies clients for rate limiting wherever NewServer keys
+	// on the client IP, including the AuthRateLimitRule pre-check. Behind a
+	// load balancer, set its TrustedProxies; otherwise every client shares
+	// the balancer's bucket.
+	ClientIP ClientIPConfig
+	// RequestID configures request ID handling. The zero value accepts
+	// X-Request-ID from clients with the default limits.
+	RequestID RequestIDConfig
+	// TracerProvider enables WithTracing. Nil disables tracing of inbound
+	// requests; pass otel.GetTracerProvider() to use the global provider.
+	TracerProvider trace.TracerProvider
+	// Metrics enables WithMetrics, records rate limit decisions and serves
+	// GET /metrics outside auth and rate limiting. Pass the same value to
+	// riot.WithCallObserver to cover Riot calls.
+	Metrics *metrics.Metrics
+	// ReadinessChecks run on GET /readyz. Nil means DefaultReadinessChecks
+	// for ReadinessRegion; append to it to also check a cache backend with
+	// PingChecker.
+	ReadinessChecks []HealthCheck
+	// ReadinessRegion is the platform whose status the default Riot check
+	// reads. Empty means DefaultReadinessRegion.
+	ReadinessRegion string
+	// BuildInfo is served on GET /version. Nil means ReadBuildInfo.
+	BuildInfo *BuildInfo
+	// Middleware runs after the built-in stack for every request.
+	Middleware []Middleware
+}
+
+func NewRouter(middlewares ...Middleware) *Router {
+	mux := http.NewServeMux()
+	return &Router{
+		mux:     mux,
+		handler: NewChain(middlewares...).Then(mux),
+	}
+}
+
+// Handle registers h for a Go 1.22 pattern such as "GET /league/challenger".
+// Route middleware runs inside the shared chain. The pattern also names the
+// request's span when WithTracing is in the chain and labels its metrics when
+// WithMetrics is.
+func (rt *Router) Handle(pattern string, h http.Handler, middlewares ...Middleware) {
+	chained := NewChain(middlewares...).Then(h)
+	rt.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nameSpan(r, pattern)
+		recordRoute(r, pattern)
+		chained.ServeHTTP(w, r)
+	}))
+}
+
+func (rt *Router) HandleFunc(pattern string, fn http.HandlerFunc, middlewares ...Middleware) {
+	rt.Handle(pattern, fn, middlewares...)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.handler.ServeHTTP(w, r)
+}
+
+// DefaultCacheControl returns the Cache-Control directive of each built-in
+// route. Ladders and summoners change within minutes; a finished match never
+// changes.
+func DefaultCacheControl() map[string]string {
+	return map[string]string{
+		"GET /summoner/by-riot-id": "public, max-age=60",
+		"GET /summoner/by-puuid":   "public, max-age=60",
+		"GET /league/challenger":   "public, max-age=300",
+		"GET /league/grandmaster":  "public, max-age=300",
+		"GET /league/master":       "public, max-age=300",
+		"GET /league/entries":      "public, max-age=300",
+		"GET /league/by-puuid":     "public, max-age=60",
+		"GET /league/rated-ladder": "public, max-age=300",
+		"GET /match/history":       "public, max-age=60",
+		"GET /match":               "public, max-age=86400, immutable",
+		"GET /match/recent":        "public, max-age=60",
+	}
+}
+
+// MountTFTRoutes registers every built-in TFT endpoint, each rate limited
+// under its Riot method family, bounded by config.Timeout and cached as
+// DefaultCacheControl and config.CacheControl say.
+func MountTFTRoutes(rt *Router, config ServerConfig) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+
+	cacheControl := DefaultCacheControl()
+	for pattern, directive := range config.CacheControl {
+		cacheControl[pattern] = directive
+	}
+
+	rateLimitOptions := config.RateLimitOptions
+	if config.Metrics != nil {
+		rateLimitOptions = append([]RateLimitOption{RateLimitRecorder(config.Metrics)}, rateLimitOptions...)
+	}
+	clientIP := ClientIPKey(config.ClientIP)
+	var auth []Middleware
+	if config.Auth != nil {
+		// Credentials are checked only after the client IP passes the "auth"
+		// rule, so failed attempts are rate limited too.
+		auth = append(auth,
+			WithRateLimit(config.RateLimiter, AuthRateLimitRule, config.Logger,
+				append([]RateLimitOption{RateLimitKey(clientIP)}, rateLimitOptions...)...),
+			WithAuth(*config.Auth, config.Logger),
+		)
+		rateLimitOptions = append([]RateLimitOption{
+			RateLimitKey(FirstKey(UserIDKey(), clientIP)),
+			RateLimitPlans(PrincipalPlans(nil)),
+		}, rateLimitOptions...)
+	} else {
+		rateLimitOptions = append([]RateLimitOption{RateLimitKey(clientIP)}, rateLimitOptions...)
+	}
+
+	mount := func(pattern, endpoint string, h http.Handler) {
+		middlewares := append(append([]Middleware{}, auth...),
+			WithRateLimit(config.RateLimiter, endpoint, config.Logger, rateLimitOptions...),
+			WithTimeout(timeout, config.Logger),
+		)
+		if directive := cacheControl[pattern]; directive != "" {
+			middlewares = append(middlewares, WithCacheControl(directive))
+		}
+		rt.Handle(pattern, h, middlewares...)
+	}
+
+	mount("GET /summoner/by-riot-id", "summoner", summonerByRiotID(config.RiotClient, config.Logger))
+	mount("GET /summoner/by-puuid", "summoner", summonerByPUUID(config.RiotClient, config.Logger))
+	mount("GET /league/challenger", "league", challengerLeague(config.RiotClient, config.Logger))
+	mount("GET /league/grandmaster", "league", grandmasterLeague(config.RiotClient, config.Logger))
+	mount("GET /league/master", "league", masterLeague(config.RiotClient, config.Logger))
+	mount("GET /league/entries", "league", leagueEntries(config.RiotClient, config.Logger))
+	mount("GET /league/by-puuid", "league", playerRank(config.RiotClient, config.Logger))
+	mount("GET /league/rated-ladder", "league", ratedLadder(config.RiotClient, config.Logger))
+	mount("GET /match/history", "match-list", matchHistory(config.RiotClient, config.Logger))
+	mount("GET /match", "match", matchByID(config.RiotClient, config.Logger))
+	mount("GET /match/recent", RecentMatchesRateLimitRule, recentMatches(config.RiotClient, config.Logger))
+}
+
+// NewServer returns an http.Server serving all TFT endpoints behind the
+// standard stack: request ID, optional tracing, response format and meta,
+// optional metrics, logging, optional compression, panic recovery and CORS,
+// then config.Middleware. GET /healthz, /readyz, /version and, with metrics,
+// /metrics are mounted outside auth and rate limiting so probes and scrapers
+// always get through.
+func NewServer(config ServerConfig) *http.Server {
+	stack := []Middleware{
+		WithRequestIDConfig(config.RequestID, config.Logger),
+	}
+	if config.TracerProvider != nil {
+		stack = append(stack, WithTracing(config.TracerProvider))
+	}
+	stack = append(stack,
+		WithResponseFormat(config.ResponseFormat),
+		WithResponseMeta,
+	)
+	if config.Metrics != nil {
+		stack = append(stack, WithMetrics(config.Metrics))
+	}
+	stack = append(stack, WithLogging(config.Logger))
+	if config.Compression != nil {
+		stack = append(stack, WithCompression(*config.Compression))
+	}
+	cors := DefaultCORSConfig()
+	if config.CORS != nil {
+		cors = *config.CORS
+	}
+	stack = append(stack, WithRecovery(config.Logger), WithCORSPolicy(cors))
+
+	router := NewRouter(append(stack, config.Middleware...)...)
+	MountTFTRoutes(router, config)
+
+	checks := config.ReadinessChecks
+	if checks == nil {
+		checks = DefaultReadinessChecks(config.RiotClient, config.RateLimiter, config.ReadinessRegion)
+	}
+	buildInfo := ReadBuildInfo()
+	if config.BuildInfo != nil {
+		buildInfo = *config.BuildInfo
+	}
+	router.Handle("GET /healthz", LivenessHandler())
+	router.Handle("GET /readyz", ReadinessHandler(checks, config.Logger))
+	router.Handle("GET /version", VersionHandler(buildInfo))
+	if config.Metrics != nil {
+		router.Handle("GET /metrics", config.Metrics.Handler())
+	}
+
+	return &http.Server{
+		Addr:              config.Addr,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"go.uber.org/zap"
+)
+
+const DefaultRequestTimeout = 10 * time.Second
+
+// WithTimeout gives each request a context deadline. The handler runs with a
+// buffered response; if the deadline passes first, a 504 GATEWAY_TIMEOUT is
+// written instead and whatever the handler writes afterwards is discarded.
+// Riot calls made with the request context are cancelled on timeout and when
+// the client disconnects.
+func WithTimeout(timeout time.Duration, log *logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header), statusCode: http.StatusOK}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						if recovered != http.ErrAbortHandler {
+							recovered = fmt.Sprintf("%v\n\n%s", recovered, debug.Stack())
+						}
+						panicked <- recovered
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case recovered := <-panicked:
+				panic(recovered)
+
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				dst := w.Header()
+				for key, values := range tw.header {
+					dst[key] = values
+				}
+				w.WriteHeader(tw.statusCode)
+				w.Write(tw.buf.Bytes())
+
+			case <-ctx.Done():
+				tw.mu.Lock()
+				tw.timedOut = true
+				tw.mu.Unlock()
+
+				if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					log.WithContext(r.Context()).Info("client disconnected",
+						zap.String("path", r.URL.Path))
+					return
+				}
+
+				log.WithContext(r.Context()).Warn("request timed out",
+					zap.String("path", r.URL.Path),
+					zap.Duration("timeout", timeout))
+				WriteError(w, "GATEWAY_TIMEOUT", "Request timed out", http.StatusGatewayTimeout, log, r)
+			}
+		})
+	}
+}
+
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.statusCode = code
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	return tw.buf.Write(b)
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+)
+
+func newTimeoutHandler(t *testing.T, timeout time.Duration, next http.HandlerFunc) http.Handler {
+	t.Helper()
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return WithTimeout(timeout, log)(next)
+}
+
+func TestWithTimeoutPassesResponse(t *testing.T) {
+	handler := newTimeoutHandler(t, time.Second, func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("request context has no deadline")
+		}
+		w.Header().Set("X-Test", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" || rec.Header().Get("X-Test") != "yes" {
+		t.Errorf("response = %d %q %v, want the handler's 201", rec.Code, rec.Body, rec.Header())
+	}
+}
+
+func TestWithTimeoutDiscardsLateWrites(t *testing.T) {
+	tests := []struct {
+		name  string
+		early bool
+		write func(w http.ResponseWriter) error
+	}{
+		{"late body", false, func(w http.ResponseWriter) error {
+			_, err := w.Write([]byte("late"))
+			return err
+		}},
+		{"late header and body", false, func(w http.ResponseWriter) error {
+			w.Header().Set("X-Late", "yes")
+			w.WriteHeader(http.StatusOK)
+			_, err := w.Write([]byte("late"))
+			return err
+		}},
+		{"partial body before deadline", true, func(w http.ResponseWriter) error {
+			_, err := w.Write([]byte("late"))
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lateErr := make(chan error, 1)
+			handler := newTimeoutHandler(t, 10*time.Millisecond, func(w http.ResponseWriter, r *http.Request) {
+				if tt.early {
+					w.Write([]byte("early"))
+				}
+				<-r.Context().Done()
+				time.Sleep(5 * time.Millisecond)
+				lateErr <- tt.write(w)
+			})
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != http.StatusGatewayTimeout || !strings.Contains(rec.Body.String(), "GATEWAY_TIMEOUT") {
+				t.Errorf("response = %d %s, want 504 GATEWAY_TIMEOUT", rec.Code, rec.Body)
+			}
+
+			select {
+			case err := <-lateErr:
+				if !errors.Is(err, http.ErrHandlerTimeout) {
+					t.Errorf("late write err = %v, want http.ErrHandlerTimeout", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("handler never finished")
+			}
+
+			if strings.Contains(rec.Body.String(), "late") || strings.Contains(rec.Body.String(), "early") || rec.Header().Get("X-Late") != "" {
+				t.Errorf("handler output leaked into the 504: %v %s", rec.Header(), rec.Body)
+			}
+		})
+	}
+}
+
+func TestWithTimeoutClientDisconnect(t *testing.T) {
+	handler := newTimeoutHandler(t, time.Second, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	if rec.Body.Len() != 0 {
+		t.Errorf("body = %s, want nothing written for a gone client", rec.Body)
+	}
+}
+
+func TestWithTimeoutRepanics(t *testing.T) {
+	handler := newTimeoutHandler(t, time.Second, func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	defer func() {
+		recovered := recover()
+		if s, ok := recovered.(string); !ok || !strings.HasPrefix(s, "boom") {
+			t.Errorf("recovered %v, want the handler's panic with its stack", recovered)
+		}
+	}()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTracedRouter(t *testing.T, middlewares ...Middleware) (*Router, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { provider.Shutdown(t.Context()) })
+
+	return NewRouter(append([]Middleware{WithTracing(provider)}, middlewares...)...), exporter
+}
+
+func onlySpan(t *testing.T, exporter *tracetest.InMemoryExporter) tracetest.SpanStub {
+	t.Helper()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	return spans[0]
+}
+
+func attributeValue(attrs []attribute.KeyValue, key attribute.Key) attribute.Value {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestWithTracingNamesSpanAfterRoute(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		handler  http.HandlerFunc
+		wantName string
+		wantCode int
+		wantErr  bool
+	}{
+		{
+			name:     "matched route",
+			path:     "/league/challenger?region=br1",
+			handler:  func(w http.ResponseWriter, r *http.Request) {},
+			wantName: "GET /league/challenger",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "server error",
+			path:     "/league/challenger",
+			handler:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+			wantName: "GET /league/challenger",
+			wantCode: http.StatusBadGateway,
+			wantErr:  true,
+		},
+		{
+			name:     "no route",
+			path:     "/unknown",
+			handler:  func(w http.ResponseWriter, r *http.Request) {},
+			wantName: "GET",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, exporter := newTracedRouter(t)
+			router.HandleFunc("GET /league/challenger", tt.handler)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			span := onlySpan(t, exporter)
+			if span.Name != tt.wantName {
+				t.Errorf("span name = %q, want %q", span.Name, tt.wantName)
+			}
+			if got := attributeValue(span.Attributes, "http.response.status_code"); got != attribute.IntValue(tt.wantCode) {
+				t.Errorf("http.response.status_code = %v, want %d", got.Emit(), tt.wantCode)
+			}
+			if gotErr := span.Status.Code == codes.Error; gotErr != tt.wantErr {
+				t.Errorf("span status = %v, want error %v", span.Status.Code, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithTracingContinuesIncomingTrace(t *testing.T) {
+	router, exporter := newTracedRouter(t)
+	router.HandleFunc("GET /league/challenger", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/league/challenger", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	span := onlySpan(t, exporter)
+	if got := span.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the incoming one", got)
+	}
+	if got := span.Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("parent span ID = %s, want the incoming one", got)
+	}
+}
+
+func TestRateLimitDecisionEvent(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.WithCleanupInterval(0))
+	rules := &ratelimit.Config{Default: ratelimit.NewRule(1, time.Minute)}
+
+	router, exporter := newTracedRouter(t)
+	router.HandleFunc("GET /league/challenger", func(w http.ResponseWriter, r *http.Request) {},
+		WithRateLimit(limiter, "league", log, RateLimitRules(rules)))
+
+	for _, wantAllowed := range []bool{true, false} {
+		exporter.Reset()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/league/challenger", nil))
+
+		span := onlySpan(t, exporter)
+		if len(span.Events) != 1 || span.Events[0].Name != "ratelimit.decision" {
+			t.Fatalf("events = %v, want one ratelimit.decision", span.Events)
+		}
+
+		attrs := span.Events[0].Attributes
+		if got := attributeValue(attrs, "ratelimit.allowed"); got != attribute.BoolValue(wantAllowed) {
+			t.Errorf("ratelimit.allowed = %v, want %v", got.Emit(), wantAllowed)
+		}
+		if got := attributeValue(attrs, "ratelimit.endpoint"); got != attribute.StringValue("league") {
+			t.Errorf("ratelimit.endpoint = %v, want league", got.Emit())
+		}
+		if got := attributeValue(attrs, "ratelimit.key_class"); got != attribute.StringValue("ip") {
+			t.Errorf("ratelimit.key_class = %v, want ip", got.Emit())
+		}
+	}
+}
@@ -3,16 +3,42 @@ package http
 import (
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/rsdlab-dk/tft-core/logger"
+	"github.com/rsdlab-dk/tft-core/riot"
 	"go.uber.org/zap"
 )
 
+const (
+	maxMatchCount   = 200
+	defaultPageSize = 50
+	maxPageSize     = 300
+)
+
 var (
 	puuidRegex    = regexp.MustCompile(`^[A-Za-z0-9_-]{78}$`)
 	gameNameRegex = regexp.MustCompile(`^[\p{L}\p{N}._\- ]{3,16}$`)
 	tagLineRegex  = regexp.MustCompile(`^[A-Za-z0-9]{3,5}$`)
+	matchIDRegex  = regexp.MustCompile(`^[A-Z0-9]{2,5}_[0-9]{1,20}$`)
+
+	validTiers = map[string]bool{
+		"IRON":     true,
+		"BRONZE":   true,
+		"SILVER":   true,
+		"GOLD":     true,
+		"PLATINUM": true,
+		"EMERALD":  true,
+		"DIAMOND":  true,
+	}
+
+	validDivisions = map[string]bool{
+		"I":   true,
+		"II":  true,
+		"III": true,
+		"IV":  true,
+	}
 )
 
 func ValidatePUUID(puuid, requestID string, log *logger.Logger, w http.ResponseWriter, r *http.Request) bool {
@@ -70,6 +96,17 @@ func ValidateRiotID(gameName, tagLine, requestID string, log *logger.Logger, w h
 	return true
 }
 
+// queryRegion reads the region parameter, br1 when absent. It is lowercased
+// here, once, because ValidateRegion accepts any case while Riot hosts and
+// riot.RegionToMatchCluster only know the lowercase names.
+func queryRegion(r *http.Request) string {
+	region := r.URL.Query().Get("region")
+	if region == "" {
+		return "br1"
+	}
+	return strings.ToLower(region)
+}
+
 func ValidateRegion(region, requestID string, log *logger.Logger, w http.ResponseWriter, r *http.Request) bool {
 	validRegions := map[string]bool{
 		"br1":  true,
@@ -103,3 +140,214 @@ func ValidateRegion(region, requestID string, log *logger.Logger, w http.Respons
 
 	return true
 }
+
+func ValidateMatchID(matchID, requestID string, log *logger.Logger, w http.ResponseWriter, r *http.Request) bool {
+	if matchID == "" {
+		log.WithContext(r.Context()).Warn("missing matchId parameter",
+			zap.String("request_id", requestID))
+		WriteBadRequest(w, "MatchId parameter is required", log, r)
+		return false
+	}
+
+	if !matchIDRegex.MatchString(matchID) {
+		log.WithContext(r.Context()).Warn("invalid matchId format",
+			zap.String("request_id", requestID),
+			zap.String("matchId", matchID))
+		WriteBadRequest(w, "Invalid MatchId format (e.g. BR1_1234567890)", log, r)
+		return false
+	}
+
+	return true
+}
+
+// ParseMatchListOptions reads the start, count, startTime and endTime query
+// parameters. Times are epoch seconds, as in the Riot match-v1 API.
+func ParseMatchListOptions(defaultCount int, requestID string, log *logger.Logger, w http.ResponseWriter, r *http.Request) (riot.MatchListOptions, bool) {
+	opts := riot.MatchListOptions{Count: defaultCount}
+	query := r.URL.Query()
+
+	params := []struct {
+		name string
+		max  int64
+		dst  func(int64)
+	}{
+		{"start", 1 << 31, func(v int64) { opts.Start = int(v) }},
+		{"count", maxMatchCount, func(v int64) { opts.Count = int(v) }},
+		{"startTime", 1 << 62, func(v int64) { opts.StartTime = v }},
+		{"endTime", 1 << 62, func(v int64) { opts.EndTime = v }},
+	}
+
+	for _, param := range params {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || value < 0 || value > param.max || (param.name == "count" && value == 0) {
+			log.WithContext(r.Context()).Warn("invalid match list parameter",
+				zap.String("request_id", requestID),
+				zap.String("parameter", param.name),
+				zap.String("value", raw))
+			WriteBadRequest(w, "Invalid "+param.name+" parameter", log, r)
+			return riot.MatchListOptions{}, false
+		}
+		param.dst(value)
+	}
+
+	if opts.StartTime > 0 && opts.EndTime > 0 && opts.EndTime <= opts.StartTime {
+		log.WithContext(r.Context()).Warn("invalid match list time range",
+			zap.String("request_id", requestID),
+			zap.Int64("startTime", opts.StartTime),
+			zap.Int64("endTime", opts.EndTime))
+		WriteBadRequest(w, "EndTime must be after StartTime", log, r)
+		return riot.MatchListOptions{}, false
+	}
+
+	return opts, true
+}
+
+// LeagueQuery filters, orders and pages ladder entries after they are fetched
+// from Riot, which returns them unsorted and unpaged.
+type LeagueQuery struct {
+	// Sort is "lp", "winrate" or "games".
+	Sort       string
+	Ascending  bool
+	MinLP      int
+	MinGames   int
+	MinWinRate float64
+	Page       int
+	// PageSize is zero when the request names neither page nor pageSize,
+	// which returns every entry that passes the filters.
+	PageSize int
+}
+
+// ValidateTierDivision accepts the divided tiers, IRON through DIAMOND, and
+// divisions I to IV. Apex tiers have their own endpoints.
+func ValidateTierDivision(tier, division, requestID string, log *logger.Logger, w http.ResponseWriter, r *http.Request) bool {
+	if !validTiers[tier] {
+		log.WithContext(r.Context()).Warn("invalid tier",
+			zap.String("request_id", requestID),
+			zap.String("tier", tier))
+		WriteBadRequest(w, "Invalid tier (IRON to DIAMOND)", log, r)
+		return false
+	}
+
+	if !validDivisions[division] {
+		log.WithContext(r.Context()).Warn("invalid division",
+			zap.String("request_id", requestID),
+			zap.String("division", division))
+		WriteBadRequest(w, "Invalid division (I to IV)", log, r)
+		return false
+	}
+
+	return true
+}
+
+// ParsePagination reads the page and pageSize query parameters. Pages start
+// at 1. When neither is given the page size is zero, meaning unpaged, so
+// ladders stay whole for existing callers.
+func ParsePagination(requestID string, log *logger.Logger, w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	page, pageSize := 1, defaultPageSize
+	query := r.URL.Query()
+	if !query.Has("page") && !query.Has("pageSize") {
+		pageSize = 0
+	}
+
+	params := []struct {
+		name string
+		max  int
+		dst  *int
+	}{
+		{"page", 1 << 20, &page},
+		{"pageSize", maxPageSize, &pageSize},
+	}
+
+	for _, param := range params {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > param.max {
+			log.WithContext(r.Context()).Warn("invalid pagination parameter",
+				zap.String("request_id", requestID),
+				zap.String("parameter", param.name),
+				zap.String("value", raw))
+			WriteBadRequest(w, "Invalid "+param.name+" parameter", log, r)
+			return 0, 0, false
+		}
+		*param.dst = value
+	}
+
+	return page, pageSize, true
+}
+
+// ParseLeagueQuery reads sort, order, minLP, minGames and minWinRate along
+// with the pagination parameters. minWinRate is a percentage. Entries are
+// sorted by LP, highest first, unless asked otherwise, and paged as
+// ParsePagination describes.
+func ParseLeagueQuery(requestID string, log *logger.Logger, w http.ResponseWriter, r *http.Request) (LeagueQuery, bool) {
+	q := LeagueQuery{Sort: "lp"}
+	query := r.URL.Query()
+
+	invalid := func(name, value string) (LeagueQuery, bool) {
+		log.WithContext(r.Context()).Warn("invalid league query parameter",
+			zap.String("request_id", requestID),
+			zap.String("parameter", name),
+			zap.String("value", value))
+		WriteBadRequest(w, "Invalid "+name+" parameter", log, r)
+		return LeagueQuery{}, false
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		if sort != "lp" && sort != "winrate" && sort != "games" {
+			return invalid("sort", sort)
+		}
+		q.Sort = sort
+	}
+
+	switch order := query.Get("order"); order {
+	case "", "desc":
+	case "asc":
+		q.Ascending = true
+	default:
+		return invalid("order", order)
+	}
+
+	params := []struct {
+		name string
+		dst  *int
+	}{
+		{"minLP", &q.MinLP},
+		{"minGames", &q.MinGames},
+	}
+
+	for _, param := range params {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return invalid(param.name, raw)
+		}
+		*param.dst = value
+	}
+
+	if raw := query.Get("minWinRate"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value < 0 || value > 100 {
+			return invalid("minWinRate", raw)
+		}
+		q.MinWinRate = value / 100
+	}
+
+	var ok bool
+	if q.Page, q.PageSize, ok = ParsePagination(requestID, log, w, r); !ok {
+		return LeagueQuery{}, false
+	}
+
+	return q, true
+}
@@ -2,6 +2,8 @@ package logger
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -30,3 +32,72 @@ func GetRequestID(ctx context.Context) string {
 func GenerateRequestID() string {
 	return uuid.New().String()
 }
+
+const traceContextKey contextKey = "trace_context"
+
+// TraceContext is a W3C Trace Context (traceparent and tracestate) received
+// with a request.
+type TraceContext struct {
+	TraceID  string
+	ParentID string
+	Flags    string
+	State    string
+}
+
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// ParseTraceParent parses a version 00 traceparent header such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". Later versions
+// are read the same way, as the specification asks.
+func ParseTraceParent(header string) (TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+	if !isLowerHex(traceID, 32) || traceID == strings.Repeat("0", 32) {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+	if !isLowerHex(parentID, 16) || parentID == strings.Repeat("0", 16) {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+	if !isLowerHex(flags, 2) {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	return TraceContext{TraceID: traceID, ParentID: parentID, Flags: flags}, nil
+}
+
+// TraceParent formats the context as a version 00 traceparent header.
+func (tc TraceContext) TraceParent() string {
+	return "00-" + tc.TraceID + "-" + tc.ParentID + "-" + tc.Flags
+}
+
+func WithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey, tc)
+}
+
+func GetTraceContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+
+	tc, ok := ctx.Value(traceContextKey).(TraceContext)
+	return tc, ok
+}
+
+func isLowerHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
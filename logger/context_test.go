@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{"valid", "00-" + traceID + "-" + parentID + "-01", false},
+		{"unsampled", "00-" + traceID + "-" + parentID + "-00", false},
+		{"surrounding space", " 00-" + traceID + "-" + parentID + "-01 ", false},
+		{"future version", "01-" + traceID + "-" + parentID + "-01", false},
+		{"future version with extra fields", "cc-" + traceID + "-" + parentID + "-01-what-the-future-holds", false},
+		{"empty", "", true},
+		{"too few fields", "00-" + traceID + "-" + parentID, true},
+		{"version 00 with extra field", "00-" + traceID + "-" + parentID + "-01-extra", true},
+		{"version ff", "ff-" + traceID + "-" + parentID + "-01", true},
+		{"short version", "0-" + traceID + "-" + parentID + "-01", true},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + parentID + "-01", true},
+		{"non-hex trace id", "00-" + "zbf92f3577b34da6a3ce929d0e0e4736" + "-" + parentID + "-01", true},
+		{"short trace id", "00-4bf92f3577b34da6-" + parentID + "-01", true},
+		{"all-zero trace id", "00-00000000000000000000000000000000-" + parentID + "-01", true},
+		{"short parent id", "00-" + traceID + "-00f067aa-01", true},
+		{"all-zero parent id", "00-" + traceID + "-0000000000000000-01", true},
+		{"long flags", "00-" + traceID + "-" + parentID + "-001", true},
+		{"non-hex flags", "00-" + traceID + "-" + parentID + "-0g", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, err := ParseTraceParent(tt.header)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTraceParent) {
+					t.Errorf("ParseTraceParent = %+v, %v; want ErrInvalidTraceParent", tc, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseTraceParent: %v", err)
+			}
+			if tc.TraceID != traceID || tc.ParentID != parentID {
+				t.Errorf("ParseTraceParent = %+v, want trace %s parent %s", tc, traceID, parentID)
+			}
+		})
+	}
+}
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tc, err := ParseTraceParent(header)
+	if err != nil {
+		t.Fatalf("ParseTraceParent: %v", err)
+	}
+	if got := tc.TraceParent(); got != header {
+		t.Errorf("TraceParent = %s, want %s", got, header)
+	}
+
+	// Later versions are sent on as version 00.
+	tc, err = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatalf("ParseTraceParent: %v", err)
+	}
+	if got := tc.TraceParent(); got != header {
+		t.Errorf("TraceParent = %s, want %s", got, header)
+	}
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -52,11 +53,22 @@ func (l *Logger) With(fields ...zap.Field) *Logger {
 }
 
 func (l *Logger) WithContext(ctx context.Context) *Logger {
-	requestID := GetRequestID(ctx)
-	if requestID != "" {
-		return l.With(zap.String("request_id", requestID))
+	var fields []zap.Field
+	if requestID := GetRequestID(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
 	}
-	return l
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()))
+	} else if tc, ok := GetTraceContext(ctx); ok {
+		fields = append(fields, zap.String("trace_id", tc.TraceID))
+	}
+
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
 }
 
 func (l *Logger) Sync() error {
@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const DefaultNamespace = "tft"
+
+type Config struct {
+	// Namespace prefixes every metric name. Empty means DefaultNamespace.
+	Namespace string
+	// Registry receives the collectors. Nil creates a registry that also
+	// exports Go runtime and process metrics. Tests pass their own registry
+	// and read it back with prometheus/testutil.
+	Registry *prometheus.Registry
+	// Buckets are the latency histogram buckets in seconds. Nil means
+	// prometheus.DefBuckets.
+	Buckets []float64
+}
+
+// Metrics holds the RED metrics of the server and its Riot client. It
+// implements riot.CallObserver and ratelimit.Recorder, so the same value is
+// handed to the client, the rate limiter and http.WithMetrics.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests      *prometheus.CounterVec
+	httpDuration      *prometheus.HistogramVec
+	httpInFlight      prometheus.Gauge
+	httpRevalidations *prometheus.CounterVec
+
+	riotRequests *prometheus.CounterVec
+	riotDuration *prometheus.HistogramVec
+
+	rateLimitDecisions *prometheus.CounterVec
+}
+
+func New(config Config) (*Metrics, error) {
+	if config.Namespace == "" {
+		config.Namespace = DefaultNamespace
+	}
+	if config.Buckets == nil {
+		config.Buckets = prometheus.DefBuckets
+	}
+
+	registry := config.Registry
+	if registry == nil {
+		registry = prometheus.NewRegistry()
+		if err := registerAll(registry,
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	m := &Metrics{
+		registry: registry,
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Inbound requests by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Inbound request latency by route, method and status code.",
+			Buckets:   config.Buckets,
+		}, []string{"route", "method", "status"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: config.Namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Inbound requests currently being served.",
+		}),
+		httpRevalidations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Subsystem: "http",
+			Name:      "revalidations_total",
+			Help:      "Conditional requests by route and result: hit when the client's copy was still fresh (304), miss otherwise.",
+		}, []string{"route", "result"}),
+		riotRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Subsystem: "riot",
+			Name:      "requests_total",
+			Help:      "Riot API calls by endpoint, region and outcome.",
+		}, []string{"endpoint", "region", "status"}),
+		riotDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Subsystem: "riot",
+			Name:      "request_duration_seconds",
+			Help:      "Riot API call latency, including time queued, by endpoint and region.",
+			Buckets:   config.Buckets,
+		}, []string{"endpoint", "region"}),
+		rateLimitDecisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Subsystem: "ratelimit",
+			Name:      "decisions_total",
+			Help:      "Rate limit decisions by rule, key class and result: allowed or denied.",
+		}, []string{"rule", "class", "result"}),
+	}
+
+	if err := registerAll(registry,
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.httpRevalidations,
+		m.riotRequests,
+		m.riotDuration,
+		m.rateLimitDecisions,
+	); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func registerAll(registry *prometheus.Registry, cs ...prometheus.Collector) error {
+	var errs []error
+	for _, c := range cs {
+		errs = append(errs, registry.Register(c))
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RequestStarted counts a request in flight and returns the function that
+// counts it out.
+func (m *Metrics) RequestStarted() func() {
+	m.httpInFlight.Inc()
+	return m.httpInFlight.Dec
+}
+
+// ObserveRequest records a served request. Route is the matched pattern, not
+// the path, to keep label cardinality bounded.
+func (m *Metrics) ObserveRequest(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(route, method, code).Inc()
+	m.httpDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// ObserveRevalidation records whether a conditional request found the
+// client's cached copy still fresh. The hit ratio per route is
+// hit / (hit + miss).
+func (m *Metrics) ObserveRevalidation(route string, fresh bool) {
+	result := "miss"
+	if fresh {
+		result = "hit"
+	}
+	m.httpRevalidations.WithLabelValues(route, result).Inc()
+}
+
+func (m *Metrics) ObserveCall(endpoint, region, status string, duration time.Duration) {
+	m.riotRequests.WithLabelValues(endpoint, region, status).Inc()
+	m.riotDuration.WithLabelValues(endpoint, region).Observe(duration.Seconds())
+}
+
+func (m *Metrics) Record(rule, class string, allowed bool) {
+	result := "denied"
+	if allowed {
+		result = "allowed"
+	}
+	m.rateLimitDecisions.WithLabelValues(rule, class, result).Inc()
+}
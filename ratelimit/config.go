@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const DefaultEnvPrefix = "TFT_RATELIMIT"
+
+type RuleSource interface {
+	GetRule(endpoint string) Rule
+	GetPlanRule(plan, endpoint string) (Rule, bool)
+}
+
+type fileConfig struct {
+	Default     *Rule             `json:"default" yaml:"default"`
+	Rules       map[string]Rule   `json:"rules" yaml:"rules"`
+	DefaultPlan string            `json:"default_plan" yaml:"default_plan"`
+	Plans       map[string]Plan   `json:"plans" yaml:"plans"`
+	Clients     map[string]string `json:"clients" yaml:"clients"`
+}
+
+type fileLimit struct {
+	Rate   int    `json:"rate" yaml:"rate"`
+	Window string `json:"window" yaml:"window"`
+}
+
+// LoadConfig builds a Config from the built-in defaults, then the YAML or JSON
+// file at path (if any), then environment variables under envPrefix (if any).
+func LoadConfig(path, envPrefix string) (*Config, error) {
+	config := NewConfig()
+
+	if path != "" {
+		if err := config.applyFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if envPrefix != "" {
+		if err := config.ApplyEnv(envPrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rate limit config: %w", err)
+	}
+
+	return config, nil
+}
+
+func (c *Config) applyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading rate limit config: %w", err)
+	}
+
+	var file fileConfig
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	default:
+		return fmt.Errorf("unsupported rate limit config format %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing rate limit config %s: %w", path, err)
+	}
+
+	if file.Default != nil {
+		c.Default = *file.Default
+	}
+	for endpoint, rule := range file.Rules {
+		c.Rules[endpoint] = rule
+	}
+	if file.DefaultPlan != "" {
+		c.DefaultPlan = file.DefaultPlan
+	}
+	for name, plan := range file.Plans {
+		c.Plans[name] = plan
+	}
+	for key, plan := range file.Clients {
+		c.Clients[key] = plan
+	}
+
+	return nil
+}
+
+// ApplyEnv overrides rules from environment variables in the Riot header
+// format: PREFIX_DEFAULT for the default rule and PREFIX_RULE_<ENDPOINT> for an
+// endpoint, where MATCH_LIST maps to the "match-list" endpoint.
+// PREFIX_DEFAULT_PLAN selects the default plan by name. Other variables
+// under the prefix, such as PREFIX_FILE, are left to the application.
+func (c *Config) ApplyEnv(prefix string) error {
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		suffix, found := strings.CutPrefix(name, prefix+"_")
+		if !found {
+			continue
+		}
+
+		switch {
+		case suffix == "DEFAULT_PLAN":
+			c.DefaultPlan = value
+
+		case suffix == "DEFAULT":
+			rule, err := ParseRule(value)
+			if err != nil {
+				return fmt.Errorf("parsing %s: %w", name, err)
+			}
+			c.Default = rule
+
+		case strings.HasPrefix(suffix, "RULE_"):
+			rule, err := ParseRule(value)
+			if err != nil {
+				return fmt.Errorf("parsing %s: %w", name, err)
+			}
+			endpoint := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(suffix, "RULE_"), "_", "-"))
+			c.Rules[endpoint] = rule
+		}
+	}
+
+	return nil
+}
+
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return r.parse(s)
+	}
+
+	var limits []fileLimit
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return fmt.Errorf("rule must be a \"count:seconds\" string or a list of limits: %w", err)
+	}
+	return r.fromLimits(limits)
+}
+
+func (r *Rule) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return r.parse(value.Value)
+	}
+
+	var limits []fileLimit
+	if err := value.Decode(&limits); err != nil {
+		return fmt.Errorf("rule must be a \"count:seconds\" string or a list of limits: %w", err)
+	}
+	return r.fromLimits(limits)
+}
+
+func (r *Rule) parse(s string) error {
+	rule, err := ParseRule(s)
+	if err != nil {
+		return err
+	}
+	*r = rule
+	return nil
+}
+
+func (r *Rule) fromLimits(limits []fileLimit) error {
+	rule := Rule{Limits: make([]Limit, 0, len(limits))}
+	for _, limit := range limits {
+		window, err := time.ParseDuration(limit.Window)
+		if err != nil {
+			return fmt.Errorf("parsing window %q: %w", limit.Window, err)
+		}
+		rule.Limits = append(rule.Limits, Limit{Rate: limit.Rate, Window: window})
+	}
+
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	*r = rule
+	return nil
+}
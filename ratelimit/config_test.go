@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("TFT_RATELIMIT_FILE", "/etc/rl.yaml")
+	t.Setenv("TFT_RATELIMIT_DEFAULT", "20:1,100:120")
+	t.Setenv("TFT_RATELIMIT_RULE_MATCH_LIST", "500:10")
+	t.Setenv("TFT_RATELIMIT_DEFAULT_PLAN", "free")
+
+	config := NewConfig()
+	if err := config.ApplyEnv("TFT_RATELIMIT"); err != nil {
+		t.Fatalf("ApplyEnv: %v", err)
+	}
+
+	if got := config.Default.String(); got != "20:1,100:120" {
+		t.Errorf("default = %s, want 20:1,100:120", got)
+	}
+	if got := config.Rules["match-list"]; got.String() != NewRule(500, 10*time.Second).String() {
+		t.Errorf("match-list = %s, want 500:10", got)
+	}
+	if config.DefaultPlan != "free" {
+		t.Errorf("default plan = %q, want free", config.DefaultPlan)
+	}
+}
+
+func TestApplyEnvInvalidRule(t *testing.T) {
+	t.Setenv("TFT_RATELIMIT_RULE_SUMMONER", "fast")
+
+	err := NewConfig().ApplyEnv("TFT_RATELIMIT")
+	if !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("err = %v, want ErrInvalidRule", err)
+	}
+}
@@ -6,32 +6,39 @@ import (
 )
 
 type Limiter interface {
-	Allow(ctx context.Context, key string, rate int, window time.Duration) (bool, error)
+	Allow(ctx context.Context, key string, rule Rule) (bool, error)
+	Reserve(ctx context.Context, key string, rule Rule) (*Reservation, error)
+	Wait(ctx context.Context, key string, rule Rule) error
 	Reset(ctx context.Context, key string) error
 	GetCount(ctx context.Context, key string) (int, error)
 }
 
 type Config struct {
-	DefaultRate   int
-	DefaultWindow time.Duration
-	Rules         map[string]Rule
-}
-
-type Rule struct {
-	Rate   int
-	Window time.Duration
+	Default     Rule
+	Rules       map[string]Rule
+	DefaultPlan string
+	Plans       map[string]Plan
+	Clients     map[string]string
 }
 
 func NewConfig() *Config {
 	return &Config{
-		DefaultRate:   100,
-		DefaultWindow: 2 * time.Minute,
+		Default: NewRule(100, 2*time.Minute),
 		Rules: map[string]Rule{
-			"summoner":   {Rate: 100, Window: 2 * time.Minute},
-			"match":      {Rate: 100, Window: 2 * time.Minute},
-			"league":     {Rate: 100, Window: 2 * time.Minute},
-			"match-list": {Rate: 1000, Window: 10 * time.Second},
+			"summoner":   NewRule(100, 2*time.Minute),
+			"match":      NewRule(100, 2*time.Minute),
+			"league":     NewRule(100, 2*time.Minute),
+			"match-list": NewRule(1000, 10*time.Second),
+			// Each request makes up to 21 Riot calls, so this is "match"
+			// divided by 20.
+			"match-recent": NewRule(5, 2*time.Minute),
+			// Checked per client IP before credentials, across all routes.
+			"auth": NewRule(100, time.Second),
+		},
+		Plans: map[string]Plan{
+			PlanUnlimited: {Unlimited: true},
 		},
+		Clients: map[string]string{},
 	}
 }
 
@@ -39,5 +46,5 @@ func (c *Config) GetRule(endpoint string) Rule {
 	if rule, exists := c.Rules[endpoint]; exists {
 		return rule
 	}
-	return Rule{Rate: c.DefaultRate, Window: c.DefaultWindow}
+	return c.Default
 }
@@ -1,86 +1,461 @@
 package ratelimit
 
 import (
+	"container/list"
 	"context"
+	"hash/fnv"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// maxReserveSteps bounds the search for a slot that satisfies every limit of
+// a rule at once.
+const maxReserveSteps = 1024
+
+const DefaultCleanupInterval = time.Minute
+
 type MemoryLimiter struct {
-	mu      sync.RWMutex
-	buckets map[string]*bucket
+	shards          []*shard
+	cleanupInterval time.Duration
+	maxKeys         int
+	done            chan struct{}
+	closeOnce       sync.Once
+}
+
+type MemoryOption func(*MemoryLimiter)
+
+// shard owns a slice of the key space behind its own lock. Entries are kept
+// in least-recently-used order so a shard can evict its coldest key when the
+// limiter is full.
+type shard struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	lru     *list.List
+	// keys counts entries across all shards and maxKeys bounds that count;
+	// peers are the other shards, to evict from when this one is empty.
+	keys    *atomic.Int64
+	maxKeys int
+	peers   []*shard
+	// generation numbers each bucket the shard creates, so a reservation can
+	// tell the bucket it was taken from apart from one recreated after a
+	// reset, eviction or sweep.
+	generation uint64
+}
+
+type entry struct {
+	key     string
+	buckets map[time.Duration]*bucket
+	element *list.Element
 }
 
 type bucket struct {
-	count     int
-	resetTime time.Time
+	count      int
+	resetTime  time.Time
+	window     time.Duration
+	pending    []int
+	generation uint64
+}
+
+// reservedSlot records where a reservation took a unit from one bucket.
+type reservedSlot struct {
+	generation uint64
+	end        time.Time
+}
+
+// WithCleanupInterval sets how often expired keys are swept. Zero disables
+// the background sweep.
+func WithCleanupInterval(interval time.Duration) MemoryOption {
+	return func(m *MemoryLimiter) {
+		m.cleanupInterval = interval
+	}
+}
+
+// WithMaxKeys bounds the number of tracked keys across all shards. When full,
+// a new key evicts the least recently used key of its own shard, or of
+// another shard when its own is empty, which resets that key's counters.
+func WithMaxKeys(maxKeys int) MemoryOption {
+	return func(m *MemoryLimiter) {
+		m.maxKeys = maxKeys
+	}
+}
+
+func WithShards(count int) MemoryOption {
+	return func(m *MemoryLimiter) {
+		if count > 0 {
+			m.shards = make([]*shard, count)
+		}
+	}
 }
 
-func NewMemoryLimiter() *MemoryLimiter {
+func NewMemoryLimiter(opts ...MemoryOption) *MemoryLimiter {
 	limiter := &MemoryLimiter{
-		buckets: make(map[string]*bucket),
+		shards:          make([]*shard, 4*runtime.GOMAXPROCS(0)),
+		cleanupInterval: DefaultCleanupInterval,
+		done:            make(chan struct{}),
 	}
 
-	go limiter.cleanup()
+	for _, opt := range opts {
+		opt(limiter)
+	}
+
+	keys := &atomic.Int64{}
+	for i := range limiter.shards {
+		limiter.shards[i] = &shard{
+			entries: make(map[string]*entry),
+			lru:     list.New(),
+			keys:    keys,
+			maxKeys: limiter.maxKeys,
+			peers:   limiter.shards,
+		}
+	}
+
+	if limiter.cleanupInterval > 0 {
+		go limiter.cleanup()
+	}
 
 	return limiter
 }
 
-func (m *MemoryLimiter) Allow(ctx context.Context, key string, rate int, window time.Duration) (bool, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// Close stops the background cleanup. The limiter keeps working afterwards,
+// but expired keys are only dropped when they are touched again.
+func (m *MemoryLimiter) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+	return nil
+}
 
-	now := time.Now()
+func (m *MemoryLimiter) Allow(ctx context.Context, key string, rule Rule) (bool, error) {
+	if err := rule.Validate(); err != nil {
+		return false, err
+	}
+
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	b, exists := m.buckets[key]
-	if !exists || now.After(b.resetTime) {
-		m.buckets[key] = &bucket{
-			count:     1,
-			resetTime: now.Add(window),
+	buckets := s.bucketsFor(key, rule, time.Now())
+	for i, limit := range rule.Limits {
+		if buckets[i].count >= limit.Rate {
+			return false, nil
 		}
-		return true, nil
 	}
 
-	if b.count >= rate {
-		return false, nil
+	for _, b := range buckets {
+		b.count++
 	}
-
-	b.count++
 	return true, nil
 }
 
+func (m *MemoryLimiter) Reserve(ctx context.Context, key string, rule Rule) (*Reservation, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	buckets := s.bucketsFor(key, rule, now)
+
+	at, slots, err := findSlot(buckets, rule, now)
+	if err != nil {
+		return nil, err
+	}
+
+	taken := make(map[time.Duration]reservedSlot, len(buckets))
+	for i, b := range buckets {
+		taken[b.window] = reservedSlot{generation: b.generation, end: b.take(slots[i])}
+	}
+
+	return NewReservation(at, func() {
+		s.release(key, taken)
+	}), nil
+}
+
+func (m *MemoryLimiter) Wait(ctx context.Context, key string, rule Rule) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	reservation, err := m.Reserve(ctx, key, rule)
+	if err != nil {
+		return err
+	}
+
+	return reservation.Wait(ctx)
+}
+
 func (m *MemoryLimiter) Reset(ctx context.Context, key string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	delete(m.buckets, key)
+	if e, exists := s.entries[key]; exists {
+		s.remove(e)
+	}
 	return nil
 }
 
 func (m *MemoryLimiter) GetCount(ctx context.Context, key string) (int, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	b, exists := m.buckets[key]
-	if !exists || time.Now().After(b.resetTime) {
+	e, exists := s.entries[key]
+	if !exists {
 		return 0, nil
 	}
 
-	return b.count, nil
+	now := time.Now()
+	count := 0
+	for _, b := range e.buckets {
+		b.advance(now)
+		if b.count > count {
+			count = b.count
+		}
+	}
+
+	return count, nil
+}
+
+func (m *MemoryLimiter) shardFor(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return m.shards[h.Sum32()%uint32(len(m.shards))]
 }
 
 func (m *MemoryLimiter) cleanup() {
-	ticker := time.NewTicker(time.Minute)
+	ticker := time.NewTicker(m.cleanupInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		m.mu.Lock()
-		now := time.Now()
-		for key, bucket := range m.buckets {
-			if now.After(bucket.resetTime) {
-				delete(m.buckets, key)
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			for _, s := range m.shards {
+				s.sweep(time.Now())
+			}
+		}
+	}
+}
+
+func (s *shard) bucketsFor(key string, rule Rule, now time.Time) []*bucket {
+	e, exists := s.entries[key]
+	if exists {
+		s.lru.MoveToFront(e.element)
+	} else {
+		if s.maxKeys > 0 && s.keys.Load() >= int64(s.maxKeys) {
+			s.evict()
+		}
+
+		e = &entry{
+			key:     key,
+			buckets: make(map[time.Duration]*bucket, len(rule.Limits)),
+		}
+		e.element = s.lru.PushFront(e)
+		s.entries[key] = e
+		s.keys.Add(1)
+	}
+
+	buckets := make([]*bucket, len(rule.Limits))
+	for i, limit := range rule.Limits {
+		b, exists := e.buckets[limit.Window]
+		if !exists {
+			s.generation++
+			b = &bucket{
+				resetTime:  now.Add(limit.Window),
+				window:     limit.Window,
+				generation: s.generation,
+			}
+			e.buckets[limit.Window] = b
+		}
+
+		b.advance(now)
+		buckets[i] = b
+	}
+
+	return buckets
+}
+
+// release gives back the units a reservation took. Buckets recreated since
+// then never held them and are left alone.
+func (s *shard) release(key string, taken map[time.Duration]reservedSlot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, exists := s.entries[key]
+	if !exists {
+		return
+	}
+
+	now := time.Now()
+	for window, slot := range taken {
+		if b, exists := e.buckets[window]; exists && b.generation == slot.generation {
+			b.advance(now)
+			b.release(slot.end)
+		}
+	}
+}
+
+func (s *shard) sweep(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		for window, b := range e.buckets {
+			if b.expired(now) {
+				delete(e.buckets, window)
 			}
 		}
-		m.mu.Unlock()
+		if len(e.buckets) == 0 {
+			s.remove(e)
+		}
+	}
+}
+
+func (s *shard) remove(e *entry) {
+	s.lru.Remove(e.element)
+	delete(s.entries, e.key)
+	s.keys.Add(-1)
+}
+
+// evict drops the coldest key of this shard, or of the first other shard
+// that has one. Other shards are only tried, never waited on, since this
+// shard's lock is held; if all of them are busy the key count briefly
+// exceeds maxKeys until a later insert evicts again.
+func (s *shard) evict() {
+	if back := s.lru.Back(); back != nil {
+		s.remove(back.Value.(*entry))
+		return
+	}
+
+	for _, peer := range s.peers {
+		if peer == s || !peer.mu.TryLock() {
+			continue
+		}
+		back := peer.lru.Back()
+		if back != nil {
+			peer.remove(back.Value.(*entry))
+		}
+		peer.mu.Unlock()
+		if back != nil {
+			return
+		}
 	}
 }
+
+// findSlot returns the earliest time at which every limit of the rule has room,
+// together with the window index to take in each bucket. Nothing is consumed,
+// so a rule either reserves in all of its windows or in none of them.
+func findSlot(buckets []*bucket, rule Rule, now time.Time) (time.Time, []int, error) {
+	at := now
+	slots := make([]int, len(buckets))
+
+	for step := 0; step < maxReserveSteps; step++ {
+		next := at
+		for i, b := range buckets {
+			slot := b.slotAt(at)
+			if b.used(slot) < rule.Limits[i].Rate {
+				slots[i] = slot
+				continue
+			}
+
+			if start := b.windowStart(slot + 1); start.After(next) {
+				next = start
+			}
+		}
+
+		if next.Equal(at) {
+			return at, slots, nil
+		}
+		at = next
+	}
+
+	return time.Time{}, nil, ErrReservationTooFar
+}
+
+// advance rolls the bucket forward to the window containing now. Windows that
+// hold reservations are kept back to back so the slots promised to waiters
+// line up with the windows they were taken from.
+func (b *bucket) advance(now time.Time) {
+	for !now.Before(b.resetTime) {
+		if len(b.pending) == 0 {
+			b.count = 0
+			b.resetTime = now.Add(b.window)
+			return
+		}
+
+		b.count = b.pending[0]
+		b.pending = b.pending[1:]
+		b.resetTime = b.resetTime.Add(b.window)
+	}
+}
+
+// slotAt returns the index of the window containing t, where 0 is the current
+// window and n is the n-th window after it.
+func (b *bucket) slotAt(t time.Time) int {
+	if t.Before(b.resetTime) {
+		return 0
+	}
+	return int(t.Sub(b.resetTime)/b.window) + 1
+}
+
+func (b *bucket) used(slot int) int {
+	if slot == 0 {
+		return b.count
+	}
+	if slot <= len(b.pending) {
+		return b.pending[slot-1]
+	}
+	return 0
+}
+
+// take consumes one unit from the given window and returns the window's end.
+func (b *bucket) take(slot int) time.Time {
+	if slot == 0 {
+		b.count++
+		return b.resetTime
+	}
+
+	for len(b.pending) < slot {
+		b.pending = append(b.pending, 0)
+	}
+	b.pending[slot-1]++
+	return b.windowStart(slot + 1)
+}
+
+func (b *bucket) release(end time.Time) {
+	if end.Equal(b.resetTime) {
+		if b.count > 0 {
+			b.count--
+		}
+		return
+	}
+
+	if !end.After(b.resetTime) {
+		return
+	}
+
+	slot := int(end.Sub(b.resetTime) / b.window)
+	if slot >= 1 && slot <= len(b.pending) && b.pending[slot-1] > 0 {
+		b.pending[slot-1]--
+	}
+
+	for len(b.pending) > 0 && b.pending[len(b.pending)-1] == 0 {
+		b.pending = b.pending[:len(b.pending)-1]
+	}
+}
+
+// windowStart returns the start of the n-th window after the current one.
+func (b *bucket) windowStart(n int) time.Time {
+	return b.resetTime.Add(time.Duration(n-1) * b.window)
+}
+
+func (b *bucket) expired(now time.Time) bool {
+	return len(b.pending) == 0 && !now.Before(b.resetTime)
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiterCancelAfterReset(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter(WithCleanupInterval(0))
+	rule := NewRule(1, time.Minute)
+
+	if allowed, err := limiter.Allow(ctx, "key", rule); err != nil || !allowed {
+		t.Fatalf("Allow = %v, %v; want true, nil", allowed, err)
+	}
+
+	reservation, err := limiter.Reserve(ctx, "key", rule)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if reservation.Delay() == 0 {
+		t.Fatal("reservation should wait for the next window")
+	}
+
+	if err := limiter.Reset(ctx, "key"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if allowed, err := limiter.Allow(ctx, "key", rule); err != nil || !allowed {
+		t.Fatalf("Allow after reset = %v, %v; want true, nil", allowed, err)
+	}
+
+	reservation.Cancel()
+
+	count, err := limiter.GetCount(ctx, "key")
+	if err != nil {
+		t.Fatalf("GetCount: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("count = %d after cancelling a stale reservation, want 1", count)
+	}
+}
+
+func TestMemoryLimiterCancelReleasesSlot(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter(WithCleanupInterval(0))
+	rule := NewRule(1, time.Minute)
+
+	first, err := limiter.Reserve(ctx, "key", rule)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if first.Delay() != 0 {
+		t.Fatalf("first reservation delay = %v, want 0", first.Delay())
+	}
+
+	first.Cancel()
+
+	if allowed, err := limiter.Allow(ctx, "key", rule); err != nil || !allowed {
+		t.Errorf("Allow after cancel = %v, %v; want true, nil", allowed, err)
+	}
+}
+
+func TestBucketReleaseIgnoresMisalignedEnd(t *testing.T) {
+	now := time.Now()
+	b := &bucket{resetTime: now, window: time.Minute, pending: []int{1}}
+
+	b.release(now.Add(30 * time.Second))
+
+	if b.pending[0] != 1 {
+		t.Errorf("pending = %v, want [1]", b.pending)
+	}
+}
+
+func TestMemoryLimiterMaxKeysIsGlobal(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter(WithCleanupInterval(0), WithMaxKeys(2), WithShards(8))
+	rule := NewRule(10, time.Minute)
+
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	for _, key := range keys {
+		if _, err := limiter.Allow(ctx, key, rule); err != nil {
+			t.Fatalf("Allow(%s): %v", key, err)
+		}
+	}
+
+	active, err := limiter.ActiveKeys(ctx)
+	if err != nil {
+		t.Fatalf("ActiveKeys: %v", err)
+	}
+	if active != 2 {
+		t.Errorf("active keys = %d, want 2", active)
+	}
+
+	last := keys[len(keys)-1]
+	if count, _ := limiter.GetCount(ctx, last); count != 1 {
+		t.Errorf("count of most recent key = %d, want 1", count)
+	}
+
+	if err := limiter.Reset(ctx, last); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if active, _ := limiter.ActiveKeys(ctx); active != 1 {
+		t.Errorf("active keys after reset = %d, want 1", active)
+	}
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+)
+
+const PlanUnlimited = "unlimited"
+
+// Plan is a named set of rules for a class of clients. Endpoint overrides
+// win over the plan default, which wins over the config-wide rules.
+type Plan struct {
+	Unlimited bool            `json:"unlimited" yaml:"unlimited"`
+	Default   *Rule           `json:"default" yaml:"default"`
+	Rules     map[string]Rule `json:"rules" yaml:"rules"`
+}
+
+type PlanLookup interface {
+	LookupPlan(ctx context.Context, key string) (string, error)
+}
+
+type StaticPlans map[string]string
+
+func (s StaticPlans) LookupPlan(ctx context.Context, key string) (string, error) {
+	return s[key], nil
+}
+
+// LookupPlan returns the plan assigned to a client key in Clients. An empty
+// result selects DefaultPlan.
+func (c *Config) LookupPlan(ctx context.Context, key string) (string, error) {
+	return c.Clients[key], nil
+}
+
+// GetPlanRule resolves the rule for an endpoint under a plan. The boolean is
+// false when the plan is unlimited and the request should not be counted.
+func (c *Config) GetPlanRule(plan, endpoint string) (Rule, bool) {
+	if plan == "" {
+		plan = c.DefaultPlan
+	}
+
+	p, exists := c.Plans[plan]
+	if !exists {
+		return c.GetRule(endpoint), true
+	}
+
+	if p.Unlimited {
+		return Rule{}, false
+	}
+
+	if rule, exists := p.Rules[endpoint]; exists {
+		return rule, true
+	}
+
+	if p.Default != nil {
+		return *p.Default, true
+	}
+
+	return c.GetRule(endpoint), true
+}
+
+// Validate checks the plan's default and endpoint rules. An unlimited plan
+// never applies them, but they are still checked so a typo does not wait for
+// the plan to change.
+func (p Plan) Validate() error {
+	if p.Default != nil {
+		if err := p.Default.Validate(); err != nil {
+			return fmt.Errorf("default rule: %w", err)
+		}
+	}
+
+	for endpoint, rule := range p.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %q: %w", endpoint, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) Validate() error {
+	if err := c.Default.Validate(); err != nil {
+		return fmt.Errorf("default rule: %w", err)
+	}
+
+	for endpoint, rule := range c.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %q: %w", endpoint, err)
+		}
+	}
+
+	for name, plan := range c.Plans {
+		if err := plan.Validate(); err != nil {
+			return fmt.Errorf("plan %q: %w", name, err)
+		}
+	}
+
+	if _, exists := c.Plans[c.DefaultPlan]; c.DefaultPlan != "" && !exists {
+		return fmt.Errorf("default plan %q is not defined", c.DefaultPlan)
+	}
+
+	for key, plan := range c.Clients {
+		if _, exists := c.Plans[plan]; !exists {
+			return fmt.Errorf("client %q uses undefined plan %q", key, plan)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newPlanConfig() *Config {
+	config := NewConfig()
+	config.Default = NewRule(100, time.Minute)
+	config.Rules = map[string]Rule{"match": NewRule(50, time.Minute)}
+	planDefault := NewRule(10, time.Minute)
+	config.Plans["free"] = Plan{
+		Default: &planDefault,
+		Rules:   map[string]Rule{"summoner": NewRule(5, time.Minute)},
+	}
+	config.Plans["pro"] = Plan{Rules: map[string]Rule{"summoner": NewRule(500, time.Minute)}}
+	config.DefaultPlan = "free"
+	config.Clients["key-pro"] = "pro"
+	config.Clients["key-internal"] = PlanUnlimited
+	return config
+}
+
+func TestGetPlanRule(t *testing.T) {
+	config := newPlanConfig()
+
+	tests := []struct {
+		name        string
+		plan        string
+		endpoint    string
+		want        string
+		wantLimited bool
+	}{
+		{"plan endpoint rule", "free", "summoner", "5:60", true},
+		{"plan default", "free", "match", "10:60", true},
+		{"empty plan uses default plan", "", "summoner", "5:60", true},
+		{"plan without default falls back to endpoint rule", "pro", "match", "50:60", true},
+		{"plan without default falls back to config default", "pro", "league", "100:60", true},
+		{"unknown plan uses config rules", "gold", "match", "50:60", true},
+		{"unlimited", PlanUnlimited, "summoner", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, limited := config.GetPlanRule(tt.plan, tt.endpoint)
+			if limited != tt.wantLimited || rule.String() != tt.want {
+				t.Errorf("GetPlanRule = %s, %v; want %s, %v", rule, limited, tt.want, tt.wantLimited)
+			}
+		})
+	}
+}
+
+func TestLookupPlan(t *testing.T) {
+	config := newPlanConfig()
+
+	for key, want := range map[string]string{
+		"key-pro":      "pro",
+		"key-internal": PlanUnlimited,
+		"key-unknown":  "",
+	} {
+		plan, err := config.LookupPlan(context.Background(), key)
+		if err != nil || plan != want {
+			t.Errorf("LookupPlan(%s) = %q, %v; want %q", key, plan, err, want)
+		}
+	}
+}
+
+func TestValidatePlans(t *testing.T) {
+	bad := Rule{Limits: []Limit{{Rate: 0, Window: time.Minute}}}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"plan default", func(c *Config) {
+			p := c.Plans["free"]
+			p.Default = &bad
+			c.Plans["free"] = p
+		}, `plan "free": default rule`},
+		{"plan endpoint rule", func(c *Config) {
+			c.Plans["pro"].Rules["summoner"] = Rule{}
+		}, `plan "pro": rule "summoner"`},
+		{"unlimited plan rule", func(c *Config) {
+			c.Plans[PlanUnlimited] = Plan{Unlimited: true, Rules: map[string]Rule{"match": bad}}
+		}, `plan "unlimited": rule "match"`},
+	}
+
+	if err := newPlanConfig().Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newPlanConfig()
+			tt.modify(config)
+
+			err := config.Validate()
+			if !errors.Is(err, ErrInvalidRule) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want ErrInvalidRule for %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigStoreRejectsBadPlanRule(t *testing.T) {
+	load := func() (*Config, error) {
+		config := newPlanConfig()
+		config.Plans["pro"].Rules["match"] = Rule{}
+		return config, nil
+	}
+
+	if _, err := NewConfigStore(load, nil); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("err = %v, want ErrInvalidRule", err)
+	}
+}
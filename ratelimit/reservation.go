@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidRule         = errors.New("invalid rate limit rule")
+	ErrWaitExceedsDeadline = errors.New("rate limit wait would exceed context deadline")
+	ErrReservationTooFar   = errors.New("no rate limit slot available within reservation horizon")
+)
+
+type Reservation struct {
+	timeToAct time.Time
+	cancel    func()
+	once      sync.Once
+}
+
+func NewReservation(timeToAct time.Time, cancel func()) *Reservation {
+	return &Reservation{
+		timeToAct: timeToAct,
+		cancel:    cancel,
+	}
+}
+
+func (r *Reservation) TimeToAct() time.Time {
+	return r.timeToAct
+}
+
+func (r *Reservation) Delay() time.Duration {
+	return r.DelayFrom(time.Now())
+}
+
+func (r *Reservation) DelayFrom(t time.Time) time.Duration {
+	delay := r.timeToAct.Sub(t)
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// Cancel gives the reserved slot back to the limiter. It is safe to call more
+// than once and is a no-op once the window the slot was taken from has ended.
+func (r *Reservation) Cancel() {
+	r.once.Do(func() {
+		if r.cancel != nil {
+			r.cancel()
+		}
+	})
+}
+
+// Wait blocks until the reservation can be acted on. The reservation is
+// cancelled if ctx ends first or its deadline falls before the reserved slot.
+func (r *Reservation) Wait(ctx context.Context) error {
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(r.timeToAct) {
+		r.Cancel()
+		return ErrWaitExceedsDeadline
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Limit struct {
+	Rate   int
+	Window time.Duration
+}
+
+// Rule is a set of limits that must all have room for a request to pass,
+// mirroring how Riot expresses limits as "20 per 1s and 100 per 2m".
+type Rule struct {
+	Limits []Limit
+}
+
+func NewRule(rate int, window time.Duration) Rule {
+	return Rule{Limits: []Limit{{Rate: rate, Window: window}}}
+}
+
+// ParseRule parses a comma-separated list of limits, each either in the
+// "count:seconds" format of the Riot X-App-Rate-Limit and X-Method-Rate-Limit
+// headers or written as "count/window" with a Go duration, where a bare unit
+// means one of it: "20:1,100:120" and "20/s,100/2m" are the same rule.
+func ParseRule(s string) (Rule, error) {
+	var rule Rule
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		limit, err := parseLimit(part)
+		if err != nil {
+			return Rule{}, err
+		}
+		rule.Limits = append(rule.Limits, limit)
+	}
+
+	if err := rule.Validate(); err != nil {
+		return Rule{}, err
+	}
+
+	return rule, nil
+}
+
+func parseLimit(part string) (Limit, error) {
+	count, window, perDuration := strings.Cut(part, "/")
+	if !perDuration {
+		var found bool
+		if count, window, found = strings.Cut(part, ":"); !found {
+			return Limit{}, fmt.Errorf("parsing limit %q: %w", part, ErrInvalidRule)
+		}
+	}
+
+	rate, err := strconv.Atoi(count)
+	if err != nil {
+		return Limit{}, fmt.Errorf("parsing limit count %q: %w", part, ErrInvalidRule)
+	}
+
+	if !perDuration {
+		secs, err := strconv.Atoi(window)
+		if err != nil {
+			return Limit{}, fmt.Errorf("parsing limit window %q: %w", part, ErrInvalidRule)
+		}
+		return Limit{Rate: rate, Window: time.Duration(secs) * time.Second}, nil
+	}
+
+	if window != "" && (window[0] < '0' || window[0] > '9') {
+		window = "1" + window
+	}
+	duration, err := time.ParseDuration(window)
+	if err != nil {
+		return Limit{}, fmt.Errorf("parsing limit window %q: %w", part, ErrInvalidRule)
+	}
+	return Limit{Rate: rate, Window: duration}, nil
+}
+
+func (r Rule) String() string {
+	parts := make([]string, 0, len(r.Limits))
+	for _, limit := range r.Limits {
+		parts = append(parts, fmt.Sprintf("%d:%d", limit.Rate, int(limit.Window/time.Second)))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (r Rule) Validate() error {
+	if len(r.Limits) == 0 {
+		return fmt.Errorf("%w: no limits", ErrInvalidRule)
+	}
+
+	seen := make(map[time.Duration]bool, len(r.Limits))
+	for _, limit := range r.Limits {
+		if limit.Rate <= 0 || limit.Window <= 0 {
+			return fmt.Errorf("%w: rate %d per %s", ErrInvalidRule, limit.Rate, limit.Window)
+		}
+		if seen[limit.Window] {
+			return fmt.Errorf("%w: duplicate window %s", ErrInvalidRule, limit.Window)
+		}
+		seen[limit.Window] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []Limit
+	}{
+		{"20:1,100:120", []Limit{{20, time.Second}, {100, 2 * time.Minute}}},
+		{"10/s,100/2m", []Limit{{10, time.Second}, {100, 2 * time.Minute}}},
+		{"10/s, 100:120", []Limit{{10, time.Second}, {100, 2 * time.Minute}}},
+		{"500/10s", []Limit{{500, 10 * time.Second}}},
+		{"30/h,", []Limit{{30, time.Hour}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			rule, err := ParseRule(tt.input)
+			if err != nil {
+				t.Fatalf("ParseRule: %v", err)
+			}
+			if !slices.Equal(rule.Limits, tt.want) {
+				t.Errorf("limits = %v, want %v", rule.Limits, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRuleInvalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		",",
+		"fast",
+		"20",
+		"20:",
+		":1",
+		"x:1",
+		"20:1s",
+		"10/",
+		"10/fortnight",
+		"0:1",
+		"20:0",
+		"-5/s",
+		"10/s,20:1",
+		"20:60,30/m",
+	} {
+		if rule, err := ParseRule(input); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("ParseRule(%q) = %v, %v; want ErrInvalidRule", input, rule, err)
+		}
+	}
+}
+
+func TestRuleStringRoundTrip(t *testing.T) {
+	rule, err := ParseRule("10/s,100/2m")
+	if err != nil {
+		t.Fatalf("ParseRule: %v", err)
+	}
+	if got := rule.String(); got != "10:1,100:120" {
+		t.Fatalf("String = %s, want 10:1,100:120", got)
+	}
+
+	again, err := ParseRule(rule.String())
+	if err != nil {
+		t.Fatalf("ParseRule(String): %v", err)
+	}
+	if !slices.Equal(again.Limits, rule.Limits) {
+		t.Errorf("round trip = %v, want %v", again.Limits, rule.Limits)
+	}
+}
+
+func TestMemoryLimiterAllowAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter(WithCleanupInterval(0))
+	rule := Rule{Limits: []Limit{{Rate: 3, Window: time.Minute}, {Rate: 1, Window: time.Hour}}}
+
+	if allowed, err := limiter.Allow(ctx, "key", rule); err != nil || !allowed {
+		t.Fatalf("first Allow = %v, %v; want true, nil", allowed, err)
+	}
+	if allowed, err := limiter.Allow(ctx, "key", rule); err != nil || allowed {
+		t.Fatalf("Allow with the hour full = %v, %v; want false, nil", allowed, err)
+	}
+
+	// The denied call must not have taken from the minute window, which so
+	// far holds only the first request.
+	minute := NewRule(2, time.Minute)
+	if allowed, _ := limiter.Allow(ctx, "key", minute); !allowed {
+		t.Error("minute window was consumed by a denied request")
+	}
+	if allowed, _ := limiter.Allow(ctx, "key", minute); allowed {
+		t.Error("minute window allowed more than its rate")
+	}
+}
+
+func TestMemoryLimiterReserveAllWindows(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter(WithCleanupInterval(0))
+	rule := Rule{Limits: []Limit{{Rate: 1, Window: time.Minute}, {Rate: 2, Window: time.Hour}}}
+
+	first, err := limiter.Reserve(ctx, "key", rule)
+	if err != nil || first.Delay() != 0 {
+		t.Fatalf("first Reserve = %v, %v; want no delay", first, err)
+	}
+
+	second, err := limiter.Reserve(ctx, "key", rule)
+	if err != nil {
+		t.Fatalf("second Reserve: %v", err)
+	}
+	if delay := second.Delay(); delay <= 0 || delay > time.Minute {
+		t.Errorf("second delay = %v, want the next minute window", delay)
+	}
+
+	// The hour window now holds both units, so a third must wait past it.
+	third, err := limiter.Reserve(ctx, "key", rule)
+	if err != nil {
+		t.Fatalf("third Reserve: %v", err)
+	}
+	if delay := third.Delay(); delay <= 59*time.Minute {
+		t.Errorf("third delay = %v, want the next hour window", delay)
+	}
+
+	// Cancelling gives the unit back in every window it was taken from.
+	second.Cancel()
+	third.Cancel()
+	if allowed, _ := limiter.Allow(ctx, "key", NewRule(2, time.Hour)); !allowed {
+		t.Error("hour window kept a cancelled reservation")
+	}
+}
+
+func TestFindSlot(t *testing.T) {
+	now := time.Now()
+	rule := Rule{Limits: []Limit{{Rate: 1, Window: time.Second}, {Rate: 2, Window: time.Minute}}}
+
+	tests := []struct {
+		name      string
+		second    int
+		minute    int
+		wantAt    time.Time
+		wantSlots []int
+	}{
+		{"both free", 0, 0, now, []int{0, 0}},
+		{"second full", 1, 1, now.Add(time.Second), []int{1, 0}},
+		{"minute full", 0, 2, now.Add(time.Minute), []int{60, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets := []*bucket{
+				{count: tt.second, resetTime: now.Add(time.Second), window: time.Second},
+				{count: tt.minute, resetTime: now.Add(time.Minute), window: time.Minute},
+			}
+
+			at, slots, err := findSlot(buckets, rule, now)
+			if err != nil {
+				t.Fatalf("findSlot: %v", err)
+			}
+			if !at.Equal(tt.wantAt) || !slices.Equal(slots, tt.wantSlots) {
+				t.Errorf("findSlot = %v, %v; want %v, %v", at.Sub(now), slots, tt.wantAt.Sub(now), tt.wantSlots)
+			}
+			if buckets[0].count != tt.second || buckets[1].count != tt.minute {
+				t.Error("findSlot consumed from a bucket")
+			}
+		})
+	}
+}
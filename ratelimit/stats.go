@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Introspector interface {
+	ActiveKeys(ctx context.Context) (int, error)
+	TopConsumers(ctx context.Context, n int) ([]Consumer, error)
+}
+
+type Consumer struct {
+	Key     string    `json:"key"`
+	Count   int       `json:"count"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+type DecisionCount struct {
+	Rule    string `json:"rule"`
+	Class   string `json:"class"`
+	Allowed uint64 `json:"allowed"`
+	Denied  uint64 `json:"denied"`
+}
+
+// Recorder receives every rate limit decision. Stats keeps them in memory for
+// the admin endpoint; metrics exporters implement it as well.
+type Recorder interface {
+	Record(rule, class string, allowed bool)
+}
+
+// Stats counts allowed and denied decisions per rule and key class.
+type Stats struct {
+	mu       sync.RWMutex
+	counters map[statsKey]*decisionCounter
+}
+
+type statsKey struct {
+	rule  string
+	class string
+}
+
+type decisionCounter struct {
+	allowed atomic.Uint64
+	denied  atomic.Uint64
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		counters: make(map[statsKey]*decisionCounter),
+	}
+}
+
+func (s *Stats) Record(rule, class string, allowed bool) {
+	key := statsKey{rule: rule, class: class}
+
+	s.mu.RLock()
+	counter, exists := s.counters[key]
+	s.mu.RUnlock()
+
+	if !exists {
+		s.mu.Lock()
+		if counter, exists = s.counters[key]; !exists {
+			counter = &decisionCounter{}
+			s.counters[key] = counter
+		}
+		s.mu.Unlock()
+	}
+
+	if allowed {
+		counter.allowed.Add(1)
+	} else {
+		counter.denied.Add(1)
+	}
+}
+
+func (s *Stats) Snapshot() []DecisionCount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make([]DecisionCount, 0, len(s.counters))
+	for key, counter := range s.counters {
+		counts = append(counts, DecisionCount{
+			Rule:    key.rule,
+			Class:   key.class,
+			Allowed: counter.allowed.Load(),
+			Denied:  counter.denied.Load(),
+		})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Rule != counts[j].Rule {
+			return counts[i].Rule < counts[j].Rule
+		}
+		return counts[i].Class < counts[j].Class
+	})
+
+	return counts
+}
+
+// KeyClass returns the class prefix of a client key, so "ip:1.2.3.4" is "ip"
+// and a composite "apikey:ab12+ip:1.2.3.4" is "apikey+ip".
+func KeyClass(key string) string {
+	parts := strings.Split(key, "+")
+	for i, part := range parts {
+		class, _, found := strings.Cut(part, ":")
+		if !found {
+			class = "unknown"
+		}
+		parts[i] = class
+	}
+	return strings.Join(parts, "+")
+}
+
+func (m *MemoryLimiter) ActiveKeys(ctx context.Context) (int, error) {
+	total := 0
+	for _, s := range m.shards {
+		s.mu.Lock()
+		total += len(s.entries)
+		s.mu.Unlock()
+	}
+	return total, nil
+}
+
+func (m *MemoryLimiter) TopConsumers(ctx context.Context, n int) ([]Consumer, error) {
+	var consumers []Consumer
+	now := time.Now()
+
+	for _, s := range m.shards {
+		s.mu.Lock()
+		for key, e := range s.entries {
+			consumer := Consumer{Key: key}
+			for _, b := range e.buckets {
+				b.advance(now)
+				if b.count > consumer.Count {
+					consumer.Count = b.count
+					consumer.ResetAt = b.resetTime
+				}
+			}
+			if consumer.Count > 0 {
+				consumers = append(consumers, consumer)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	sort.Slice(consumers, func(i, j int) bool {
+		if consumers[i].Count != consumers[j].Count {
+			return consumers[i].Count > consumers[j].Count
+		}
+		return consumers[i].Key < consumers[j].Key
+	})
+
+	if n > 0 && len(consumers) > n {
+		consumers = consumers[:n]
+	}
+
+	return consumers, nil
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"go.uber.org/zap"
+)
+
+type ConfigLoader func() (*Config, error)
+
+// ConfigStore holds the active Config and swaps it atomically on reload.
+// Counters live in the Limiter, so reloading never resets them.
+type ConfigStore struct {
+	current atomic.Pointer[Config]
+	load    ConfigLoader
+	log     *logger.Logger
+}
+
+func NewConfigStore(load ConfigLoader, log *logger.Logger) (*ConfigStore, error) {
+	store := &ConfigStore{
+		load: load,
+		log:  log,
+	}
+
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func FileLoader(path, envPrefix string) ConfigLoader {
+	return func() (*Config, error) {
+		return LoadConfig(path, envPrefix)
+	}
+}
+
+func (s *ConfigStore) Config() *Config {
+	return s.current.Load()
+}
+
+func (s *ConfigStore) GetRule(endpoint string) Rule {
+	return s.current.Load().GetRule(endpoint)
+}
+
+func (s *ConfigStore) GetPlanRule(plan, endpoint string) (Rule, bool) {
+	return s.current.Load().GetPlanRule(plan, endpoint)
+}
+
+func (s *ConfigStore) LookupPlan(ctx context.Context, key string) (string, error) {
+	return s.current.Load().LookupPlan(ctx, key)
+}
+
+func (s *ConfigStore) Reload() error {
+	config, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	// Custom loaders may skip the check LoadConfig makes.
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid rate limit config: %w", err)
+	}
+
+	s.current.Store(config)
+	return nil
+}
+
+// WatchFile polls path every interval and reloads when its modification time
+// or size changes. It returns when ctx is done.
+func (s *ConfigStore) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last, _ := os.Stat(path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				s.log.Warn("rate limit config stat failed",
+					zap.String("path", path),
+					zap.Error(err))
+				continue
+			}
+
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+
+			// A failed reload, such as one that read a half-written file,
+			// leaves last alone so the next tick tries again.
+			if s.reload("file change") {
+				last = info
+			}
+		}
+	}
+}
+
+// WatchSignal reloads on every SIGHUP, or on sigs if given. It returns when
+// ctx is done.
+func (s *ConfigStore) WatchSignal(ctx context.Context, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-ch:
+			s.reload(sig.String())
+		}
+	}
+}
+
+func (s *ConfigStore) reload(trigger string) bool {
+	if err := s.Reload(); err != nil {
+		s.log.Error("rate limit config reload failed, keeping previous config",
+			zap.String("trigger", trigger),
+			zap.Error(err))
+		return false
+	}
+
+	s.log.Info("rate limit config reloaded",
+		zap.String("trigger", trigger),
+		zap.Int("rules", len(s.Config().Rules)))
+	return true
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+)
+
+func TestWatchFileRetriesFailedReload(t *testing.T) {
+	log, err := logger.New("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ratelimit.yaml")
+	if err := os.WriteFile(path, []byte(`default: "100:120"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The loader stands in for reading a file that is still being written:
+	// it fails until the write is done, while the file's stat stays put.
+	var (
+		halfWritten atomic.Bool
+		loads       atomic.Int32
+		rate        atomic.Int32
+	)
+	rate.Store(100)
+	load := func() (*Config, error) {
+		loads.Add(1)
+		if halfWritten.Load() {
+			return nil, errors.New("unexpected end of file")
+		}
+		config := NewConfig()
+		config.Default = NewRule(int(rate.Load()), time.Minute)
+		return config, nil
+	}
+
+	store, err := NewConfigStore(load, log)
+	if err != nil {
+		t.Fatalf("NewConfigStore: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.WatchFile(ctx, path, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	halfWritten.Store(true)
+	rate.Store(200)
+	if err := os.WriteFile(path, []byte(`default: "200:60"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "a failed reload", func() bool { return loads.Load() > 1 })
+
+	halfWritten.Store(false)
+	waitFor(t, "the reload to be retried", func() bool {
+		return store.GetRule("any").String() == "200:60"
+	})
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
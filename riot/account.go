@@ -13,7 +13,7 @@ func (c *Client) GetAccountByRiotID(ctx context.Context, cluster, gameName, tagL
 	endpoint := fmt.Sprintf("%s/riot/account/v1/accounts/by-riot-id/%s/%s",
 		c.getClusterURL("account", cluster), encodedGameName, encodedTagLine)
 
-	body, err := c.makeRequest(ctx, "GET", endpoint)
+	body, err := c.makeRequest(ctx, cluster, "account.by-riot-id", "GET", endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("get account by riot id %s#%s: %w", gameName, tagLine, err)
 	}
@@ -30,7 +30,7 @@ func (c *Client) GetAccountByPUUID(ctx context.Context, cluster, puuid string) (
 	endpoint := fmt.Sprintf("%s/riot/account/v1/accounts/by-puuid/%s",
 		c.getClusterURL("account", cluster), puuid)
 
-	body, err := c.makeRequest(ctx, "GET", endpoint)
+	body, err := c.makeRequest(ctx, cluster, "account.by-puuid", "GET", endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("get account by puuid %s: %w", puuid, err)
 	}
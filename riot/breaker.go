@@ -0,0 +1,265 @@
+package riot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"go.uber.org/zap"
+)
+
+var ErrCircuitOpen = errors.New("riot circuit open")
+
+type CircuitOpenError struct {
+	Region     string
+	Endpoint   string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.RetryAfter <= 0 {
+		return fmt.Sprintf("riot circuit open for %s on %s", e.Endpoint, e.Region)
+	}
+	return fmt.Sprintf("riot circuit open for %s on %s, retry in %s", e.Endpoint, e.Region, e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens
+	// the circuit.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before probing.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is how many probes may run at once while half-open,
+	// and how many must succeed to close the circuit again.
+	HalfOpenRequests int
+	OnStateChange    func(region, endpoint string, from, to CircuitState)
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// CircuitBreaker tracks one circuit per region and endpoint, so an outage of
+// one Riot platform or method does not block the others.
+type CircuitBreaker struct {
+	config   BreakerConfig
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state      CircuitState
+	generation uint64
+	failures   int
+	successes  int
+	probes     int
+	openedAt   time.Time
+}
+
+type stateChange struct {
+	region, endpoint string
+	from, to         CircuitState
+}
+
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	defaults := DefaultBreakerConfig()
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaults.OpenTimeout
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = defaults.HalfOpenRequests
+	}
+
+	return &CircuitBreaker{
+		config:   config,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// LogStateChanges returns an OnStateChange callback that reports transitions
+// through log.
+func LogStateChanges(log *logger.Logger) func(region, endpoint string, from, to CircuitState) {
+	return func(region, endpoint string, from, to CircuitState) {
+		fields := []zap.Field{
+			zap.String("region", region),
+			zap.String("endpoint", endpoint),
+			zap.String("from", from.String()),
+			zap.String("to", to.String()),
+		}
+
+		if to == CircuitOpen {
+			log.Warn("riot circuit opened", fields...)
+			return
+		}
+		log.Info("riot circuit state changed", fields...)
+	}
+}
+
+// Allow admits a call or fails with *CircuitOpenError. The returned func must
+// be called with the outcome of the call.
+func (b *CircuitBreaker) Allow(region, endpoint string) (func(error), error) {
+	b.mu.Lock()
+
+	now := time.Now()
+	c := b.circuitFor(region, endpoint)
+	var change *stateChange
+
+	if c.state == CircuitOpen {
+		if wait := c.openedAt.Add(b.config.OpenTimeout).Sub(now); wait > 0 {
+			b.mu.Unlock()
+			return nil, &CircuitOpenError{Region: region, Endpoint: endpoint, RetryAfter: wait}
+		}
+		change = b.transition(c, region, endpoint, CircuitHalfOpen, now)
+	}
+
+	if c.state == CircuitHalfOpen {
+		if c.probes >= b.config.HalfOpenRequests {
+			b.mu.Unlock()
+			b.notify(change)
+			return nil, &CircuitOpenError{Region: region, Endpoint: endpoint}
+		}
+		c.probes++
+	}
+
+	generation := c.generation
+	b.mu.Unlock()
+	b.notify(change)
+
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			b.record(c, region, endpoint, generation, err)
+		})
+	}, nil
+}
+
+func (b *CircuitBreaker) State(region, endpoint string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.circuitFor(region, endpoint).state
+}
+
+func (b *CircuitBreaker) circuitFor(region, endpoint string) *circuit {
+	key := region + "|" + endpoint
+	c, exists := b.circuits[key]
+	if !exists {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+func (b *CircuitBreaker) record(c *circuit, region, endpoint string, generation uint64, err error) {
+	b.mu.Lock()
+
+	// Outcomes of calls admitted under an earlier state are stale.
+	if generation != c.generation || errors.Is(err, context.Canceled) {
+		if generation == c.generation && c.state == CircuitHalfOpen {
+			c.probes--
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	var change *stateChange
+	failed := isUpstreamFailure(err)
+
+	switch c.state {
+	case CircuitClosed:
+		if !failed {
+			c.failures = 0
+			break
+		}
+		c.failures++
+		if c.failures >= b.config.FailureThreshold {
+			change = b.transition(c, region, endpoint, CircuitOpen, time.Now())
+		}
+
+	case CircuitHalfOpen:
+		c.probes--
+		if failed {
+			change = b.transition(c, region, endpoint, CircuitOpen, time.Now())
+			break
+		}
+		c.successes++
+		if c.successes >= b.config.HalfOpenRequests {
+			change = b.transition(c, region, endpoint, CircuitClosed, time.Now())
+		}
+	}
+
+	b.mu.Unlock()
+	b.notify(change)
+}
+
+func (b *CircuitBreaker) transition(c *circuit, region, endpoint string, to CircuitState, now time.Time) *stateChange {
+	change := &stateChange{region: region, endpoint: endpoint, from: c.state, to: to}
+
+	c.state = to
+	c.generation++
+	c.failures = 0
+	c.successes = 0
+	c.probes = 0
+	if to == CircuitOpen {
+		c.openedAt = now
+	}
+
+	return change
+}
+
+func (b *CircuitBreaker) notify(change *stateChange) {
+	if change != nil && b.config.OnStateChange != nil {
+		b.config.OnStateChange(change.region, change.endpoint, change.from, change.to)
+	}
+}
+
+// isUpstreamFailure reports whether err means Riot could not serve the call:
+// 5xx responses and transport errors count, 4xx responses and callers giving
+// up do not.
+func isUpstreamFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var riotErr *RiotError
+	if errors.As(err, &riotErr) {
+		return riotErr.IsServerError()
+	}
+
+	return true
+}
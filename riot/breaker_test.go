@@ -0,0 +1,179 @@
+package riot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// callBreaker admits one call and records err as its outcome.
+func callBreaker(t *testing.T, b *CircuitBreaker, err error) {
+	t.Helper()
+	done, allowErr := b.Allow("br1", "match")
+	if allowErr != nil {
+		t.Fatalf("Allow: %v", allowErr)
+	}
+	done(err)
+}
+
+func TestCircuitBreakerCountsFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want CircuitState
+	}{
+		{"server error", NewRiotError(http.StatusServiceUnavailable, "unavailable"), CircuitOpen},
+		{"wrapped server error", fmt.Errorf("get match: %w", NewRiotError(http.StatusInternalServerError, "error")), CircuitOpen},
+		{"transport error", errors.New("connection refused"), CircuitOpen},
+		{"deadline", context.DeadlineExceeded, CircuitOpen},
+		{"client error", NewRiotError(http.StatusNotFound, "not found"), CircuitClosed},
+		{"rate limited", NewRiotError(http.StatusTooManyRequests, "rate limited"), CircuitClosed},
+		{"cancelled", context.Canceled, CircuitClosed},
+		{"success", nil, CircuitClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 3})
+
+			for range 3 {
+				callBreaker(t, b, tt.err)
+			}
+
+			if got := b.State("br1", "match"); got != tt.want {
+				t.Errorf("state = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 3})
+	failure := NewRiotError(http.StatusBadGateway, "bad gateway")
+
+	for _, err := range []error{failure, failure, nil, failure, failure} {
+		callBreaker(t, b, err)
+	}
+
+	if got := b.State("br1", "match"); got != CircuitClosed {
+		t.Errorf("state = %s, want closed after a success between failures", got)
+	}
+}
+
+func TestCircuitBreakerOpenRejects(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+	callBreaker(t, b, errors.New("connection reset"))
+
+	_, err := b.Allow("br1", "match")
+	var open *CircuitOpenError
+	if !errors.As(err, &open) || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want CircuitOpenError", err)
+	}
+	if open.RetryAfter <= 0 || open.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %s, want up to the open timeout", open.RetryAfter)
+	}
+
+	if _, err := b.Allow("euw1", "match"); err != nil {
+		t.Errorf("other region: %v, want its own circuit", err)
+	}
+	if _, err := b.Allow("br1", "summoner"); err != nil {
+		t.Errorf("other endpoint: %v, want its own circuit", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		want  CircuitState
+		trail []CircuitState
+	}{
+		{"probe succeeds", nil, CircuitClosed, []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}},
+		{"probe fails", NewRiotError(http.StatusServiceUnavailable, "unavailable"), CircuitOpen, []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var trail []CircuitState
+			b := NewCircuitBreaker(BreakerConfig{
+				FailureThreshold: 1,
+				OpenTimeout:      10 * time.Millisecond,
+				OnStateChange: func(region, endpoint string, from, to CircuitState) {
+					trail = append(trail, to)
+				},
+			})
+
+			callBreaker(t, b, errors.New("connection reset"))
+			time.Sleep(20 * time.Millisecond)
+
+			probe, err := b.Allow("br1", "match")
+			if err != nil {
+				t.Fatalf("probe Allow: %v", err)
+			}
+			if got := b.State("br1", "match"); got != CircuitHalfOpen {
+				t.Fatalf("state during probe = %s, want half-open", got)
+			}
+			if _, err := b.Allow("br1", "match"); !errors.Is(err, ErrCircuitOpen) {
+				t.Errorf("second call during probe: %v, want ErrCircuitOpen", err)
+			}
+
+			probe(tt.err)
+
+			if got := b.State("br1", "match"); got != tt.want {
+				t.Errorf("state = %s, want %s", got, tt.want)
+			}
+			if !slices.Equal(trail, tt.trail) {
+				t.Errorf("transitions = %v, want %v", trail, tt.trail)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerCancelledProbeFreesSlot(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: 10 * time.Millisecond})
+	callBreaker(t, b, errors.New("connection reset"))
+	time.Sleep(20 * time.Millisecond)
+
+	probe, err := b.Allow("br1", "match")
+	if err != nil {
+		t.Fatalf("probe Allow: %v", err)
+	}
+	probe(context.Canceled)
+
+	if got := b.State("br1", "match"); got != CircuitHalfOpen {
+		t.Errorf("state = %s, want half-open", got)
+	}
+	if _, err := b.Allow("br1", "match"); err != nil {
+		t.Errorf("next probe: %v, want the cancelled probe's slot back", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresStaleOutcomes(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	// A slow call admitted while closed finishes after the circuit opened.
+	slow, err := b.Allow("br1", "match")
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	callBreaker(t, b, errors.New("connection reset"))
+	slow(nil)
+
+	if got := b.State("br1", "match"); got != CircuitOpen {
+		t.Errorf("state = %s, want open despite the stale success", got)
+	}
+
+	// Recording twice counts once.
+	b = NewCircuitBreaker(BreakerConfig{FailureThreshold: 2})
+	done, _ := b.Allow("br1", "match")
+	done(errors.New("connection reset"))
+	done(errors.New("connection reset"))
+
+	if got := b.State("br1", "match"); got != CircuitClosed {
+		t.Errorf("state after a double record = %s, want closed", got)
+	}
+}
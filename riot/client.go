@@ -3,21 +3,102 @@ package riot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/rsdlab-dk/tft-core/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const tracerName = "github.com/rsdlab-dk/tft-core/riot"
+
 type Client struct {
-	apiKey     string
-	httpClient *http.Client
-	baseURL    map[string]string
+	apiKey      string
+	httpClient  *http.Client
+	baseURL     map[string]string
+	scheduler   *Scheduler
+	concurrency *ConcurrencyLimiter
+	breaker     *CircuitBreaker
+	// requestIDHeader forwards the inbound request ID to Riot for log
+	// correlation at proxies in between. Empty disables it.
+	requestIDHeader string
+	tracer          trace.Tracer
+	observer        CallObserver
+}
+
+// CallObserver is told about every call once it completes. Status is the
+// HTTP status Riot answered with, or why no answer was received:
+// "circuit_open", "queue_full", "concurrency_limited", "canceled", "timeout"
+// or "error".
+type CallObserver interface {
+	ObserveCall(endpoint, region, status string, duration time.Duration)
+}
+
+type ClientOption func(*Client)
+
+func WithScheduler(scheduler *Scheduler) ClientOption {
+	return func(c *Client) {
+		c.scheduler = scheduler
+	}
+}
+
+func WithConcurrencyLimiter(limiter *ConcurrencyLimiter) ClientOption {
+	return func(c *Client) {
+		c.concurrency = limiter
+	}
+}
+
+func WithCircuitBreaker(breaker *CircuitBreaker) ClientOption {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// WithRequestIDHeader sets the header used to forward the request ID found in
+// the call's context. Empty disables forwarding.
+func WithRequestIDHeader(header string) ClientOption {
+	return func(c *Client) {
+		c.requestIDHeader = header
+	}
+}
+
+// WithTracerProvider sets where Riot call spans go. By default the global
+// provider is used, which records nothing until an SDK is installed.
+func WithTracerProvider(provider trace.TracerProvider) ClientOption {
+	return func(c *Client) {
+		c.tracer = provider.Tracer(tracerName)
+	}
+}
+
+// WithCallObserver reports each call's outcome and duration, e.g. to a
+// metrics exporter.
+func WithCallObserver(observer CallObserver) ClientOption {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
+// WithHTTPClient replaces the default client, which has a 10 second timeout,
+// e.g. to tune its transport.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
 }
 
-func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey: apiKey,
+func NewClient(apiKey string, opts ...ClientOption) *Client {
+	client := &Client{
+		apiKey:          apiKey,
+		requestIDHeader: "X-Request-ID",
+		tracer:          otel.GetTracerProvider().Tracer(tracerName),
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -26,11 +107,113 @@ func NewClient(apiKey string) *Client {
 			"summoner": "https://%s.api.riotgames.com",
 			"league":   "https://%s.api.riotgames.com",
 			"match":    "https://%s.api.riotgames.com",
+			"status":   "https://%s.api.riotgames.com",
 		},
 	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
+}
+
+// makeRequest performs a call against a platform ("br1") or regional cluster
+// ("americas") host. The endpoint name identifies the Riot method for
+// scheduling and diagnostics. Each call is a client span covering the time
+// spent queued as well as on the wire.
+func (c *Client) makeRequest(ctx context.Context, region, endpoint, method, url string) ([]byte, error) {
+	ctx, span := c.tracer.Start(ctx, "riot "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("riot.endpoint", endpoint),
+			attribute.String("riot.region", region),
+			attribute.String("http.request.method", method),
+		))
+	defer span.End()
+
+	start := time.Now()
+	body, err := c.execute(ctx, region, endpoint, method, url)
+	if c.observer != nil {
+		c.observer.ObserveCall(endpoint, region, callStatus(err), time.Since(start))
+	}
+
+	var riotErr *RiotError
+	switch {
+	case err == nil:
+		span.SetAttributes(attribute.Int("http.response.status_code", http.StatusOK))
+	case errors.As(err, &riotErr):
+		span.SetAttributes(attribute.Int("http.response.status_code", riotErr.StatusCode))
+		fallthrough
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return body, err
+}
+
+func callStatus(err error) string {
+	var riotErr *RiotError
+	var netErr interface{ Timeout() bool }
+
+	switch {
+	case err == nil:
+		return strconv.Itoa(http.StatusOK)
+	case errors.As(err, &riotErr):
+		return strconv.Itoa(riotErr.StatusCode)
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrQueueFull):
+		return "queue_full"
+	case errors.Is(err, ErrConcurrencyLimited):
+		return "concurrency_limited"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "error"
+	}
 }
 
-func (c *Client) makeRequest(ctx context.Context, method, url string) ([]byte, error) {
+func (c *Client) execute(ctx context.Context, region, endpoint, method, url string) ([]byte, error) {
+	record := func(error) {}
+	if c.breaker != nil {
+		var err error
+		if record, err = c.breaker.Allow(region, endpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.scheduler != nil {
+		if err := c.scheduler.Acquire(ctx, region); err != nil {
+			// The call never reached Riot, so it says nothing about its health.
+			record(context.Canceled)
+			return nil, fmt.Errorf("scheduling %s: %w", endpoint, err)
+		}
+		trace.SpanFromContext(ctx).AddEvent("riot.scheduled")
+	}
+
+	release := func(error) {}
+	if c.concurrency != nil {
+		var err error
+		if release, err = c.concurrency.Acquire(region); err != nil {
+			record(context.Canceled)
+			return nil, fmt.Errorf("calling %s: %w", endpoint, err)
+		}
+	}
+
+	body, err := c.doRequest(ctx, method, url)
+	release(err)
+	record(err)
+
+	return body, err
+}
+
+func (c *Client) doRequest(ctx context.Context, method, url string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
@@ -40,6 +223,18 @@ func (c *Client) makeRequest(ctx context.Context, method, url string) ([]byte, e
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "TFT-Arena/1.0")
 
+	if requestID := logger.GetRequestID(ctx); requestID != "" && c.requestIDHeader != "" {
+		req.Header.Set(c.requestIDHeader, requestID)
+	}
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	} else if tc, ok := logger.GetTraceContext(ctx); ok {
+		req.Header.Set("traceparent", tc.TraceParent())
+		if tc.State != "" {
+			req.Header.Set("tracestate", tc.State)
+		}
+	}
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("making request: %w", err)
@@ -52,13 +247,17 @@ func (c *Client) makeRequest(ctx context.Context, method, url string) ([]byte, e
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, c.handleErrorResponse(resp.StatusCode, body)
+		riotErr := c.handleErrorResponse(resp.StatusCode, body)
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			riotErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, riotErr
 	}
 
 	return body, nil
 }
 
-func (c *Client) handleErrorResponse(statusCode int, body []byte) error {
+func (c *Client) handleErrorResponse(statusCode int, body []byte) *RiotError {
 	var riotErr RiotAPIError
 	if err := json.Unmarshal(body, &riotErr); err != nil {
 		return NewRiotError(statusCode, string(body))
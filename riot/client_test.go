@@ -0,0 +1,196 @@
+package riot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rsdlab-dk/tft-core/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...ClientOption) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClient("test-key", opts...)
+	for service := range client.baseURL {
+		client.baseURL[service] = server.URL + "/%s"
+	}
+	return client
+}
+
+func newTestTracer() (*tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	exporter := tracetest.NewInMemoryExporter()
+	return exporter, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+}
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestClientSpan(t *testing.T) {
+	exporter, provider := newTestTracer()
+	var traceparent string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"tier":"CHALLENGER","entries":[]}`))
+	}, WithTracerProvider(provider))
+
+	if _, err := client.GetChallengerLeague(context.Background(), "br1"); err != nil {
+		t.Fatalf("GetChallengerLeague: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+
+	if span.Name != "riot league.challenger" {
+		t.Errorf("span name = %q, want %q", span.Name, "riot league.challenger")
+	}
+	if span.Status.Code == codes.Error {
+		t.Errorf("span status = %v, want unset", span.Status)
+	}
+
+	attrs := spanAttributes(span)
+	for key, want := range map[attribute.Key]attribute.Value{
+		"riot.endpoint":             attribute.StringValue("league.challenger"),
+		"riot.region":               attribute.StringValue("br1"),
+		"http.request.method":       attribute.StringValue("GET"),
+		"http.response.status_code": attribute.IntValue(http.StatusOK),
+	} {
+		if got := attrs[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got.Emit(), want.Emit())
+		}
+	}
+
+	if traceparent == "" || traceparent[3:35] != span.SpanContext.TraceID().String() {
+		t.Errorf("traceparent = %q, want trace %s", traceparent, span.SpanContext.TraceID())
+	}
+}
+
+func TestClientSpanError(t *testing.T) {
+	exporter, provider := newTestTracer()
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}, WithTracerProvider(provider))
+
+	_, err := client.GetSummonerByPUUID(context.Background(), "br1", "missing")
+	var riotErr *RiotError
+	if !errors.As(err, &riotErr) || !riotErr.IsNotFound() {
+		t.Fatalf("err = %v, want a 404 RiotError", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+
+	if span.Status.Code != codes.Error {
+		t.Errorf("span status = %v, want error", span.Status.Code)
+	}
+	if got := spanAttributes(span)["http.response.status_code"]; got != attribute.IntValue(http.StatusNotFound) {
+		t.Errorf("http.response.status_code = %v, want 404", got.Emit())
+	}
+	if len(span.Events) == 0 || span.Events[0].Name != "exception" {
+		t.Errorf("events = %v, want a recorded exception", span.Events)
+	}
+}
+
+func TestCallStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, "200"},
+		{fmt.Errorf("get match: %w", NewRiotError(http.StatusNotFound, "not found")), "404"},
+		{NewRiotError(http.StatusServiceUnavailable, "unavailable"), "503"},
+		{&CircuitOpenError{Region: "br1", Endpoint: "match"}, "circuit_open"},
+		{fmt.Errorf("scheduling match: %w", ErrQueueFull), "queue_full"},
+		{fmt.Errorf("calling match: %w", ErrConcurrencyLimited), "concurrency_limited"},
+		{fmt.Errorf("scheduling match: %w", context.DeadlineExceeded), "timeout"},
+		{fmt.Errorf("making request: %w", context.Canceled), "canceled"},
+		{errors.New("connection refused"), "error"},
+	}
+
+	for _, tt := range tests {
+		if got := callStatus(tt.err); got != tt.want {
+			t.Errorf("callStatus(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCallObserverMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m, err := metrics.New(metrics.Config{Registry: registry})
+	if err != nil {
+		t.Fatalf("metrics.New: %v", err)
+	}
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "missing") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"puuid":"found"}`))
+	}, WithCallObserver(m))
+
+	ctx := context.Background()
+	client.GetSummonerByPUUID(ctx, "br1", "found")
+	client.GetSummonerByPUUID(ctx, "br1", "found")
+	client.GetSummonerByPUUID(ctx, "kr", "missing")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	client.GetSummonerByPUUID(canceled, "br1", "found")
+
+	want := `
+# HELP tft_riot_requests_total Riot API calls by endpoint, region and outcome.
+# TYPE tft_riot_requests_total counter
+tft_riot_requests_total{endpoint="summoner.by-puuid",region="br1",status="200"} 2
+tft_riot_requests_total{endpoint="summoner.by-puuid",region="br1",status="canceled"} 1
+tft_riot_requests_total{endpoint="summoner.by-puuid",region="kr",status="404"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "tft_riot_requests_total"); err != nil {
+		t.Error(err)
+	}
+	if got := testutil.CollectAndCount(registry, "tft_riot_request_duration_seconds"); got != 2 {
+		t.Errorf("duration series = %d, want 2", got)
+	}
+}
+
+func TestRiotErrorRetryAfter(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "12")
+		http.Error(w, `{"status":{"message":"Rate limit exceeded","status_code":429}}`, http.StatusTooManyRequests)
+	})
+
+	_, err := client.GetPlatformStatus(context.Background(), "br1")
+
+	var riotErr *RiotError
+	if !errors.As(err, &riotErr) {
+		t.Fatalf("err = %v, want *RiotError", err)
+	}
+	if riotErr.RetryAfter != 12*time.Second {
+		t.Errorf("RetryAfter = %s, want 12s", riotErr.RetryAfter)
+	}
+}
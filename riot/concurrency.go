@@ -0,0 +1,178 @@
+package riot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+var ErrConcurrencyLimited = errors.New("riot concurrency limit reached")
+
+type ConcurrencyLimitError struct {
+	Region string
+	Limit  int
+}
+
+func (e *ConcurrencyLimitError) Error() string {
+	return fmt.Sprintf("riot concurrency limit reached: %d requests in flight to %s", e.Limit, e.Region)
+}
+
+func (e *ConcurrencyLimitError) Unwrap() error {
+	return ErrConcurrencyLimited
+}
+
+// ConcurrencyConfig fields left zero take their value from
+// DefaultConcurrencyConfig.
+type ConcurrencyConfig struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// BackoffRatio multiplies the limit whenever a call shows congestion.
+	BackoffRatio float64
+	// LatencyThreshold marks successful calls slower than it as congestion.
+	// Negative disables the latency signal.
+	LatencyThreshold time.Duration
+}
+
+func DefaultConcurrencyConfig() ConcurrencyConfig {
+	return ConcurrencyConfig{
+		InitialLimit:     20,
+		MinLimit:         1,
+		MaxLimit:         200,
+		BackoffRatio:     0.9,
+		LatencyThreshold: 2 * time.Second,
+	}
+}
+
+// ConcurrencyLimiter bounds in-flight Riot calls per region with an AIMD
+// limit: it grows by one for each healthy call made while at least half
+// utilized and shrinks by BackoffRatio on 429 and 5xx responses, timeouts or
+// calls slower than LatencyThreshold. Calls over the limit are rejected at once.
+type ConcurrencyLimiter struct {
+	config  ConcurrencyConfig
+	mu      sync.Mutex
+	regions map[string]*aimdLimit
+}
+
+type aimdLimit struct {
+	limit    float64
+	inFlight int
+}
+
+func NewConcurrencyLimiter(config ConcurrencyConfig) *ConcurrencyLimiter {
+	defaults := DefaultConcurrencyConfig()
+	if config.MinLimit <= 0 {
+		config.MinLimit = defaults.MinLimit
+	}
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = defaults.MaxLimit
+	}
+	if config.MaxLimit < config.MinLimit {
+		config.MaxLimit = config.MinLimit
+	}
+	if config.InitialLimit <= 0 {
+		config.InitialLimit = defaults.InitialLimit
+	}
+	config.InitialLimit = min(max(config.InitialLimit, config.MinLimit), config.MaxLimit)
+	if config.BackoffRatio <= 0 || config.BackoffRatio >= 1 {
+		config.BackoffRatio = defaults.BackoffRatio
+	}
+	if config.LatencyThreshold == 0 {
+		config.LatencyThreshold = defaults.LatencyThreshold
+	}
+
+	return &ConcurrencyLimiter{
+		config:  config,
+		regions: make(map[string]*aimdLimit),
+	}
+}
+
+// Acquire takes an in-flight slot for region. The returned func must be
+// called with the outcome of the call to release the slot.
+func (l *ConcurrencyLimiter) Acquire(region string) (func(error), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.regionFor(region)
+	limit := int(state.limit)
+	if state.inFlight >= limit {
+		return nil, &ConcurrencyLimitError{Region: region, Limit: limit}
+	}
+
+	state.inFlight++
+	start := time.Now()
+
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			l.release(state, time.Since(start), err)
+		})
+	}, nil
+}
+
+func (l *ConcurrencyLimiter) Limit(region string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.regionFor(region).limit)
+}
+
+func (l *ConcurrencyLimiter) InFlight(region string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.regionFor(region).inFlight
+}
+
+func (l *ConcurrencyLimiter) regionFor(region string) *aimdLimit {
+	state, exists := l.regions[region]
+	if !exists {
+		state = &aimdLimit{limit: float64(l.config.InitialLimit)}
+		l.regions[region] = state
+	}
+	return state
+}
+
+func (l *ConcurrencyLimiter) release(state *aimdLimit, latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inFlight := state.inFlight
+	state.inFlight--
+
+	// A caller giving up says nothing about upstream health.
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if isCongestion(err) || (l.config.LatencyThreshold > 0 && latency > l.config.LatencyThreshold) {
+		state.limit = math.Max(float64(l.config.MinLimit), math.Floor(state.limit*l.config.BackoffRatio))
+		return
+	}
+
+	if float64(inFlight)*2 >= state.limit {
+		state.limit = math.Min(float64(l.config.MaxLimit), state.limit+1)
+	}
+}
+
+func isCongestion(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var riotErr *RiotError
+	if errors.As(err, &riotErr) {
+		return riotErr.IsRateLimited() || riotErr.IsServerError()
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
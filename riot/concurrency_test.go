@@ -0,0 +1,147 @@
+package riot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewConcurrencyLimiterDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ConcurrencyConfig
+		want   int
+	}{
+		{"zero config", ConcurrencyConfig{}, 20},
+		{"only max", ConcurrencyConfig{MaxLimit: 100}, 20},
+		{"max below default initial", ConcurrencyConfig{MaxLimit: 10}, 10},
+		{"min above default initial", ConcurrencyConfig{MinLimit: 30}, 30},
+		{"explicit initial", ConcurrencyConfig{InitialLimit: 7}, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewConcurrencyLimiter(tt.config).Limit("br1"); got != tt.want {
+				t.Errorf("limit = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// acquireN takes n slots and fails the test if any is refused.
+func acquireN(t *testing.T, l *ConcurrencyLimiter, n int) []func(error) {
+	t.Helper()
+	releases := make([]func(error), n)
+	for i := range releases {
+		release, err := l.Acquire("br1")
+		if err != nil {
+			t.Fatalf("Acquire %d: %v", i+1, err)
+		}
+		releases[i] = release
+	}
+	return releases
+}
+
+func TestConcurrencyLimiterAdditiveIncrease(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyConfig{InitialLimit: 4, MaxLimit: 10})
+
+	releases := acquireN(t, l, 2)
+	releases[0](nil)
+	if got := l.Limit("br1"); got != 5 {
+		t.Errorf("limit after a healthy call at half utilization = %d, want 5", got)
+	}
+
+	releases[1](nil)
+	if got := l.Limit("br1"); got != 5 {
+		t.Errorf("limit after a healthy call below half utilization = %d, want 5", got)
+	}
+}
+
+func TestConcurrencyLimiterMultiplicativeBackoff(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"riot 429", NewRiotError(http.StatusTooManyRequests, "rate limited")},
+		{"riot 503", fmt.Errorf("get match: %w", NewRiotError(http.StatusServiceUnavailable, "unavailable"))},
+		{"deadline", fmt.Errorf("making request: %w", context.DeadlineExceeded)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewConcurrencyLimiter(ConcurrencyConfig{InitialLimit: 20, BackoffRatio: 0.5})
+
+			acquireN(t, l, 1)[0](tt.err)
+
+			if got := l.Limit("br1"); got != 10 {
+				t.Errorf("limit = %d, want 10", got)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiterIgnoresNonCongestion(t *testing.T) {
+	for _, err := range []error{
+		context.Canceled,
+		NewRiotError(http.StatusNotFound, "not found"),
+	} {
+		l := NewConcurrencyLimiter(ConcurrencyConfig{InitialLimit: 20})
+
+		acquireN(t, l, 1)[0](err)
+
+		if got := l.Limit("br1"); got != 20 {
+			t.Errorf("%v: limit = %d, want 20", err, got)
+		}
+	}
+}
+
+func TestConcurrencyLimiterSlowCallBacksOff(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyConfig{InitialLimit: 20, BackoffRatio: 0.5, LatencyThreshold: time.Millisecond})
+
+	release := acquireN(t, l, 1)[0]
+	time.Sleep(5 * time.Millisecond)
+	release(nil)
+
+	if got := l.Limit("br1"); got != 10 {
+		t.Errorf("limit = %d, want 10", got)
+	}
+}
+
+func TestConcurrencyLimiterBounds(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyConfig{InitialLimit: 3, MinLimit: 2, MaxLimit: 3, BackoffRatio: 0.1})
+
+	for _, release := range acquireN(t, l, 3) {
+		release(nil)
+	}
+	if got := l.Limit("br1"); got != 3 {
+		t.Errorf("limit after healthy calls at the max = %d, want 3", got)
+	}
+
+	for range 3 {
+		acquireN(t, l, 1)[0](context.DeadlineExceeded)
+	}
+	if got := l.Limit("br1"); got != 2 {
+		t.Errorf("limit after repeated congestion = %d, want the min of 2", got)
+	}
+}
+
+func TestConcurrencyLimiterRejectsOverLimit(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyConfig{InitialLimit: 2})
+
+	releases := acquireN(t, l, 2)
+	if _, err := l.Acquire("br1"); !errors.Is(err, ErrConcurrencyLimited) {
+		t.Errorf("err = %v, want ErrConcurrencyLimited", err)
+	}
+	if _, err := l.Acquire("euw1"); err != nil {
+		t.Errorf("other region: %v, want its own limit", err)
+	}
+
+	releases[0](nil)
+	releases[0](nil)
+	if got := l.InFlight("br1"); got != 1 {
+		t.Errorf("in flight after a double release = %d, want 1", got)
+	}
+}
@@ -0,0 +1,44 @@
+package riot
+
+import "context"
+
+type contextKey string
+
+const (
+	priorityKey contextKey = "priority"
+	tenantKey   contextKey = "tenant"
+)
+
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey, priority)
+}
+
+func PriorityFromContext(ctx context.Context) Priority {
+	if ctx == nil {
+		return PriorityInteractive
+	}
+
+	priority, ok := ctx.Value(priorityKey).(Priority)
+	if !ok {
+		return PriorityInteractive
+	}
+
+	return clampPriority(priority)
+}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	tenant, ok := ctx.Value(tenantKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return tenant
+}
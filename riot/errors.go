@@ -1,10 +1,15 @@
 package riot
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type RiotError struct {
 	StatusCode int
 	Message    string
+	// RetryAfter is Riot's Retry-After on a 429, zero when it sent none.
+	RetryAfter time.Duration
 }
 
 func (e *RiotError) Error() string {
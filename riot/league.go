@@ -10,7 +10,7 @@ func (c *Client) GetChallengerLeague(ctx context.Context, region string) (*Leagu
 	endpoint := fmt.Sprintf("%s/tft/league/v1/challenger",
 		c.getRegionURL("league", region))
 
-	body, err := c.makeRequest(ctx, "GET", endpoint)
+	body, err := c.makeRequest(ctx, region, "league.challenger", "GET", endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("get challenger league: %w", err)
 	}
@@ -27,7 +27,7 @@ func (c *Client) GetGrandmasterLeague(ctx context.Context, region string) (*Leag
 	endpoint := fmt.Sprintf("%s/tft/league/v1/grandmaster",
 		c.getRegionURL("league", region))
 
-	body, err := c.makeRequest(ctx, "GET", endpoint)
+	body, err := c.makeRequest(ctx, region, "league.grandmaster", "GET", endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("get grandmaster league: %w", err)
 	}
@@ -44,7 +44,7 @@ func (c *Client) GetMasterLeague(ctx context.Context, region string) (*LeagueLis
 	endpoint := fmt.Sprintf("%s/tft/league/v1/master",
 		c.getRegionURL("league", region))
 
-	body, err := c.makeRequest(ctx, "GET", endpoint)
+	body, err := c.makeRequest(ctx, region, "league.master", "GET", endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("get master league: %w", err)
 	}
@@ -58,10 +58,16 @@ func (c *Client) GetMasterLeague(ctx context.Context, region string) (*LeagueLis
 }
 
 func (c *Client) GetLeagueEntriesByTier(ctx context.Context, region, tier, division string) ([]LeagueEntry, error) {
-	endpoint := fmt.Sprintf("%s/tft/league/v1/entries/%s/%s",
-		c.getRegionURL("league", region), tier, division)
+	return c.GetLeagueEntriesByTierPage(ctx, region, tier, division, 1)
+}
+
+// GetLeagueEntriesByTierPage fetches one page of a tier and division. Riot
+// pages start at 1 and an empty result means the last page was passed.
+func (c *Client) GetLeagueEntriesByTierPage(ctx context.Context, region, tier, division string, page int) ([]LeagueEntry, error) {
+	endpoint := fmt.Sprintf("%s/tft/league/v1/entries/%s/%s?page=%d",
+		c.getRegionURL("league", region), tier, division, page)
 
-	body, err := c.makeRequest(ctx, "GET", endpoint)
+	body, err := c.makeRequest(ctx, region, "league.entries", "GET", endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("get league entries by tier %s/%s: %w", tier, division, err)
 	}
@@ -74,6 +80,40 @@ func (c *Client) GetLeagueEntriesByTier(ctx context.Context, region, tier, divis
 	return entries, nil
 }
 
+func (c *Client) GetLeagueEntriesByPUUID(ctx context.Context, region, puuid string) ([]LeagueEntry, error) {
+	endpoint := fmt.Sprintf("%s/tft/league/v1/by-puuid/%s",
+		c.getRegionURL("league", region), puuid)
+
+	body, err := c.makeRequest(ctx, region, "league.by-puuid", "GET", endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("get league entries by puuid %s: %w", puuid, err)
+	}
+
+	var entries []LeagueEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("unmarshaling league entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (c *Client) GetRatedLadder(ctx context.Context, region, queue string) ([]RatedLadderEntry, error) {
+	endpoint := fmt.Sprintf("%s/tft/league/v1/rated-ladders/%s/top",
+		c.getRegionURL("league", region), queue)
+
+	body, err := c.makeRequest(ctx, region, "league.rated-ladder", "GET", endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("get rated ladder %s: %w", queue, err)
+	}
+
+	var ladder []RatedLadderEntry
+	if err := json.Unmarshal(body, &ladder); err != nil {
+		return nil, fmt.Errorf("unmarshaling rated ladder: %w", err)
+	}
+
+	return ladder, nil
+}
+
 func (c *Client) FindPlayerInHighElo(ctx context.Context, region, puuid string) (*LeagueItem, error) {
 	leagues := []func(context.Context, string) (*LeagueList, error){
 		c.GetChallengerLeague,
@@ -0,0 +1,87 @@
+package riot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+type MatchListOptions struct {
+	Start     int
+	Count     int
+	StartTime int64
+	EndTime   int64
+}
+
+func (c *Client) GetMatchIDsByPUUID(ctx context.Context, cluster, puuid string, opts MatchListOptions) ([]string, error) {
+	query := url.Values{}
+	if opts.Start > 0 {
+		query.Set("start", strconv.Itoa(opts.Start))
+	}
+	if opts.Count > 0 {
+		query.Set("count", strconv.Itoa(opts.Count))
+	}
+	if opts.StartTime > 0 {
+		query.Set("startTime", strconv.FormatInt(opts.StartTime, 10))
+	}
+	if opts.EndTime > 0 {
+		query.Set("endTime", strconv.FormatInt(opts.EndTime, 10))
+	}
+
+	endpoint := fmt.Sprintf("%s/tft/match/v1/matches/by-puuid/%s/ids",
+		c.getClusterURL("match", cluster), puuid)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	body, err := c.makeRequest(ctx, cluster, "match.ids-by-puuid", "GET", endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("get match ids by puuid %s: %w", puuid, err)
+	}
+
+	var matchIDs []string
+	if err := json.Unmarshal(body, &matchIDs); err != nil {
+		return nil, fmt.Errorf("unmarshaling match ids: %w", err)
+	}
+
+	return matchIDs, nil
+}
+
+func (c *Client) GetMatch(ctx context.Context, cluster, matchID string) (*Match, error) {
+	endpoint := fmt.Sprintf("%s/tft/match/v1/matches/%s",
+		c.getClusterURL("match", cluster), matchID)
+
+	body, err := c.makeRequest(ctx, cluster, "match.by-id", "GET", endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("get match %s: %w", matchID, err)
+	}
+
+	var match Match
+	if err := json.Unmarshal(body, &match); err != nil {
+		return nil, fmt.Errorf("unmarshaling match: %w", err)
+	}
+
+	return &match, nil
+}
+
+func (m *Match) Participant(puuid string) (*Participant, bool) {
+	for i := range m.Info.Participants {
+		if m.Info.Participants[i].PUUID == puuid {
+			return &m.Info.Participants[i], true
+		}
+	}
+	return nil, false
+}
+
+// RegionToMatchCluster returns the routing value for match-v1, which serves
+// the SEA platforms from the "sea" cluster unlike account-v1.
+func RegionToMatchCluster(region string) string {
+	switch region {
+	case "oc1", "ph2", "sg2", "th2", "tw2", "vn2":
+		return "sea"
+	default:
+		return RegionToCluster(region)
+	}
+}
@@ -51,6 +51,45 @@ type LeagueItem struct {
 	HotStreak    bool   `json:"hotStreak"`
 }
 
+type RatedLadderEntry struct {
+	PUUID                        string `json:"puuid"`
+	RatedTier                    string `json:"ratedTier"`
+	RatedRating                  int    `json:"ratedRating"`
+	Wins                         int    `json:"wins"`
+	PreviousUpdateLadderPosition int    `json:"previousUpdateLadderPosition"`
+}
+
+func (e LeagueEntry) LP() int {
+	return e.LeaguePoints
+}
+
+func (e LeagueEntry) GamesPlayed() int {
+	return e.Wins + e.Losses
+}
+
+func (e LeagueEntry) WinRate() float64 {
+	return winRate(e.Wins, e.Losses)
+}
+
+func (i LeagueItem) LP() int {
+	return i.LeaguePoints
+}
+
+func (i LeagueItem) GamesPlayed() int {
+	return i.Wins + i.Losses
+}
+
+func (i LeagueItem) WinRate() float64 {
+	return winRate(i.Wins, i.Losses)
+}
+
+func winRate(wins, losses int) float64 {
+	if wins+losses == 0 {
+		return 0
+	}
+	return float64(wins) / float64(wins+losses)
+}
+
 type Match struct {
 	Metadata MatchMetadata `json:"metadata"`
 	Info     MatchInfo     `json:"info"`
@@ -112,6 +151,30 @@ type Unit struct {
 	Tier        int    `json:"tier"`
 }
 
+type PlatformData struct {
+	ID           string           `json:"id"`
+	Name         string           `json:"name"`
+	Locales      []string         `json:"locales"`
+	Maintenances []PlatformStatus `json:"maintenances"`
+	Incidents    []PlatformStatus `json:"incidents"`
+}
+
+type PlatformStatus struct {
+	ID                int             `json:"id"`
+	MaintenanceStatus string          `json:"maintenance_status"`
+	IncidentSeverity  string          `json:"incident_severity"`
+	Titles            []StatusContent `json:"titles"`
+	Platforms         []string        `json:"platforms"`
+	CreatedAt         string          `json:"created_at"`
+	UpdatedAt         string          `json:"updated_at"`
+	ArchiveAt         string          `json:"archive_at"`
+}
+
+type StatusContent struct {
+	Locale  string `json:"locale"`
+	Content string `json:"content"`
+}
+
 type RiotAPIError struct {
 	Status Status `json:"status"`
 }
@@ -0,0 +1,437 @@
+package riot
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+)
+
+type Priority int
+
+const (
+	PriorityInteractive Priority = iota
+	PriorityBackground
+	PriorityBulk
+)
+
+const numPriorities = 3
+
+var (
+	ErrQueueFull       = errors.New("riot request queue full")
+	ErrSchedulerClosed = errors.New("riot request scheduler closed")
+)
+
+type QueueFullError struct {
+	Region   string
+	Priority Priority
+	Depth    int
+}
+
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("riot request queue full: %d %s requests waiting for %s", e.Depth, e.Priority, e.Region)
+}
+
+func (e *QueueFullError) Unwrap() error {
+	return ErrQueueFull
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBackground:
+		return "background"
+	case PriorityBulk:
+		return "bulk"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
+type SchedulerConfig struct {
+	// Rule is the Riot budget shared by every priority class, per region.
+	Rule ratelimit.Rule
+	// InteractiveReserve is the fraction of each window that background and
+	// bulk requests may not use, keeping room for user-facing lookups.
+	InteractiveReserve float64
+	// MaxQueueDepth bounds waiting requests per class. Zero means unbounded.
+	MaxQueueDepth map[Priority]int
+}
+
+// DefaultSchedulerConfig matches the limits of a Riot development key.
+func DefaultSchedulerConfig() SchedulerConfig {
+	return SchedulerConfig{
+		Rule: ratelimit.Rule{Limits: []ratelimit.Limit{
+			{Rate: 20, Window: time.Second},
+			{Rate: 100, Window: 2 * time.Minute},
+		}},
+		InteractiveReserve: 0.2,
+		MaxQueueDepth: map[Priority]int{
+			PriorityInteractive: 100,
+			PriorityBackground:  1000,
+			PriorityBulk:        10000,
+		},
+	}
+}
+
+// Scheduler paces outbound Riot calls per region. Requests are served in
+// strict priority order and round-robin between tenants within a class.
+type Scheduler struct {
+	limiter   ratelimit.Limiter
+	config    SchedulerConfig
+	shared    ratelimit.Rule
+	mu        sync.Mutex
+	regions   map[string]*regionQueue
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type regionQueue struct {
+	region  string
+	classes [numPriorities]classQueue
+	wake    chan struct{}
+}
+
+type classQueue struct {
+	tenants map[string]*list.List
+	ring    []string
+	next    int
+	depth   int
+}
+
+type waiter struct {
+	priority Priority
+	tenant   string
+	element  *list.Element
+	ready    chan error
+}
+
+func NewScheduler(limiter ratelimit.Limiter, config SchedulerConfig) (*Scheduler, error) {
+	if err := config.Rule.Validate(); err != nil {
+		return nil, fmt.Errorf("scheduler rule: %w", err)
+	}
+
+	if config.InteractiveReserve < 0 || config.InteractiveReserve >= 1 {
+		return nil, fmt.Errorf("interactive reserve must be in [0, 1), got %g", config.InteractiveReserve)
+	}
+
+	shared := ratelimit.Rule{Limits: make([]ratelimit.Limit, len(config.Rule.Limits))}
+	for i, limit := range config.Rule.Limits {
+		rate := int(float64(limit.Rate) * (1 - config.InteractiveReserve))
+		if rate < 1 {
+			rate = 1
+		}
+		shared.Limits[i] = ratelimit.Limit{Rate: rate, Window: limit.Window}
+	}
+
+	return &Scheduler{
+		limiter: limiter,
+		config:  config,
+		shared:  shared,
+		regions: make(map[string]*regionQueue),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Acquire blocks until the request may be sent to region. The priority and
+// tenant are taken from ctx; see WithPriority and WithTenant.
+func (s *Scheduler) Acquire(ctx context.Context, region string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	priority := PriorityFromContext(ctx)
+	w := &waiter{
+		priority: priority,
+		tenant:   TenantFromContext(ctx),
+		ready:    make(chan error, 1),
+	}
+
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		return ErrSchedulerClosed
+	default:
+	}
+
+	q := s.regionFor(region)
+	class := &q.classes[priority]
+	if maxDepth := s.config.MaxQueueDepth[priority]; maxDepth > 0 && class.depth >= maxDepth {
+		s.mu.Unlock()
+		return &QueueFullError{Region: region, Priority: priority, Depth: class.depth}
+	}
+
+	class.push(w)
+	s.mu.Unlock()
+	q.signal()
+
+	select {
+	case err := <-w.ready:
+		return err
+	case <-ctx.Done():
+		s.mu.Lock()
+		removed := class.remove(w)
+		s.mu.Unlock()
+
+		if removed {
+			q.signal()
+		}
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) QueueDepth(region string, priority Priority) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, exists := s.regions[region]
+	if !exists {
+		return 0
+	}
+	return q.classes[clampPriority(priority)].depth
+}
+
+// Close stops dispatching and fails every waiting request with
+// ErrSchedulerClosed.
+func (s *Scheduler) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		close(s.done)
+		for _, q := range s.regions {
+			for i := range q.classes {
+				q.classes[i].drain(ErrSchedulerClosed)
+			}
+		}
+	})
+	return nil
+}
+
+func (s *Scheduler) regionFor(region string) *regionQueue {
+	q, exists := s.regions[region]
+	if !exists {
+		q = &regionQueue{
+			region: region,
+			wake:   make(chan struct{}, 1),
+		}
+		for i := range q.classes {
+			q.classes[i].tenants = make(map[string]*list.List)
+		}
+		s.regions[region] = q
+
+		go s.dispatch(q)
+	}
+	return q
+}
+
+func (s *Scheduler) dispatch(q *regionQueue) {
+	for {
+		s.mu.Lock()
+		w := q.head()
+		s.mu.Unlock()
+
+		if w == nil {
+			select {
+			case <-q.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+
+		reservations, delay, err := s.reserve(q.region, w.priority)
+		if err != nil {
+			s.mu.Lock()
+			if q.classes[w.priority].dispatch(w) {
+				w.ready <- err
+			}
+			s.mu.Unlock()
+			continue
+		}
+
+		if delay > 0 && !s.waitTurn(q, w, delay) {
+			cancelReservations(reservations)
+			select {
+			case <-s.done:
+				return
+			default:
+				continue
+			}
+		}
+
+		s.mu.Lock()
+		if q.classes[w.priority].dispatch(w) {
+			w.ready <- nil
+		} else {
+			cancelReservations(reservations)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// waitTurn sleeps until the reservation held for w is due. It reports false,
+// so the slot is given back and the head picked again, when w left the queue,
+// a higher priority request arrived or the scheduler closed. Other arrivals
+// leave the reservation alone, so a steady stream of them does not churn it.
+func (s *Scheduler) waitTurn(q *regionQueue, w *waiter, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			return true
+		case <-s.done:
+			return false
+		case <-q.wake:
+			s.mu.Lock()
+			head := q.head()
+			queued := w.element != nil
+			s.mu.Unlock()
+
+			if !queued || head == nil || head.priority < w.priority {
+				return false
+			}
+		}
+	}
+}
+
+func (s *Scheduler) reserve(region string, priority Priority) ([]*ratelimit.Reservation, time.Duration, error) {
+	ctx := context.Background()
+
+	full, err := s.limiter.Reserve(ctx, "riot:"+region, s.config.Rule)
+	if err != nil {
+		return nil, 0, err
+	}
+	reservations := []*ratelimit.Reservation{full}
+
+	if priority != PriorityInteractive && s.config.InteractiveReserve > 0 {
+		shared, err := s.limiter.Reserve(ctx, "riot:"+region+":shared", s.shared)
+		if err != nil {
+			full.Cancel()
+			return nil, 0, err
+		}
+		reservations = append(reservations, shared)
+	}
+
+	var delay time.Duration
+	for _, reservation := range reservations {
+		delay = max(delay, reservation.Delay())
+	}
+
+	return reservations, delay, nil
+}
+
+func cancelReservations(reservations []*ratelimit.Reservation) {
+	for _, reservation := range reservations {
+		reservation.Cancel()
+	}
+}
+
+func (q *regionQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *regionQueue) head() *waiter {
+	for i := range q.classes {
+		if w := q.classes[i].peek(); w != nil {
+			return w
+		}
+	}
+	return nil
+}
+
+func (c *classQueue) push(w *waiter) {
+	l, exists := c.tenants[w.tenant]
+	if !exists {
+		l = list.New()
+		c.tenants[w.tenant] = l
+		c.ring = append(c.ring, w.tenant)
+	}
+
+	w.element = l.PushBack(w)
+	c.depth++
+}
+
+func (c *classQueue) peek() *waiter {
+	if len(c.ring) == 0 {
+		return nil
+	}
+	return c.tenants[c.ring[c.next]].Front().Value.(*waiter)
+}
+
+// dispatch removes a waiter that is being served and moves the round-robin
+// cursor past its tenant. It reports false if the waiter already left.
+func (c *classQueue) dispatch(w *waiter) bool {
+	i := slices.Index(c.ring, w.tenant)
+	if !c.remove(w) {
+		return false
+	}
+
+	if _, exists := c.tenants[w.tenant]; exists {
+		c.next = (i + 1) % len(c.ring)
+	}
+	return true
+}
+
+func (c *classQueue) remove(w *waiter) bool {
+	if w.element == nil {
+		return false
+	}
+
+	l := c.tenants[w.tenant]
+	l.Remove(w.element)
+	w.element = nil
+	c.depth--
+
+	if l.Len() == 0 {
+		delete(c.tenants, w.tenant)
+
+		i := slices.Index(c.ring, w.tenant)
+		c.ring = slices.Delete(c.ring, i, i+1)
+		if i < c.next {
+			c.next--
+		}
+		if c.next >= len(c.ring) {
+			c.next = 0
+		}
+	}
+
+	return true
+}
+
+func (c *classQueue) drain(err error) {
+	for _, l := range c.tenants {
+		for e := l.Front(); e != nil; e = e.Next() {
+			w := e.Value.(*waiter)
+			w.element = nil
+			w.ready <- err
+		}
+	}
+
+	c.tenants = make(map[string]*list.List)
+	c.ring = nil
+	c.next = 0
+	c.depth = 0
+}
+
+func clampPriority(p Priority) Priority {
+	if p < PriorityInteractive {
+		return PriorityInteractive
+	}
+	if p > PriorityBulk {
+		return PriorityBulk
+	}
+	return p
+}
@@ -0,0 +1,205 @@
+package riot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rsdlab-dk/tft-core/ratelimit"
+)
+
+// countingLimiter counts reservations, so tests can see the dispatcher
+// giving slots back and reserving again.
+type countingLimiter struct {
+	ratelimit.Limiter
+	reserves atomic.Int32
+}
+
+func (l *countingLimiter) Reserve(ctx context.Context, key string, rule ratelimit.Rule) (*ratelimit.Reservation, error) {
+	l.reserves.Add(1)
+	return l.Limiter.Reserve(ctx, key, rule)
+}
+
+func newTestScheduler(t *testing.T, rate int, window time.Duration, maxDepth map[Priority]int) (*Scheduler, *countingLimiter) {
+	t.Helper()
+
+	limiter := &countingLimiter{Limiter: ratelimit.NewMemoryLimiter(ratelimit.WithCleanupInterval(0))}
+	scheduler, err := NewScheduler(limiter, SchedulerConfig{
+		Rule:          ratelimit.NewRule(rate, window),
+		MaxQueueDepth: maxDepth,
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	t.Cleanup(func() { scheduler.Close() })
+	return scheduler, limiter
+}
+
+// waitForDepth polls until depth requests of priority are queued for br1.
+func waitForDepth(t *testing.T, s *Scheduler, priority Priority, depth int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for s.QueueDepth("br1", priority) != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s queue depth = %d, want %d", priority, s.QueueDepth("br1", priority), depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// acquireAsync queues a request and reports its label on order once served.
+func acquireAsync(s *Scheduler, ctx context.Context, label string, order chan<- string) {
+	go func() {
+		if err := s.Acquire(ctx, "br1"); err == nil {
+			order <- label
+		}
+	}()
+}
+
+func TestSchedulerPriorityOrder(t *testing.T) {
+	s, _ := newTestScheduler(t, 1, 50*time.Millisecond, nil)
+	ctx := context.Background()
+
+	if err := s.Acquire(ctx, "br1"); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	order := make(chan string, 2)
+	acquireAsync(s, WithPriority(ctx, PriorityBulk), "bulk", order)
+	waitForDepth(t, s, PriorityBulk, 1)
+	acquireAsync(s, WithPriority(ctx, PriorityInteractive), "interactive", order)
+
+	for _, want := range []string{"interactive", "bulk"} {
+		select {
+		case got := <-order:
+			if got != want {
+				t.Fatalf("served %s, want %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+}
+
+func TestSchedulerQueueFull(t *testing.T) {
+	s, _ := newTestScheduler(t, 1, time.Minute, map[Priority]int{PriorityBulk: 1})
+	ctx := WithPriority(context.Background(), PriorityBulk)
+
+	if err := s.Acquire(ctx, "br1"); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	queued := make(chan error, 1)
+	go func() { queued <- s.Acquire(ctx, "br1") }()
+	waitForDepth(t, s, PriorityBulk, 1)
+
+	var queueFull *QueueFullError
+	if err := s.Acquire(ctx, "br1"); !errors.As(err, &queueFull) || !errors.Is(err, ErrQueueFull) {
+		t.Errorf("err = %v, want QueueFullError", err)
+	}
+	if err := s.Acquire(WithPriority(context.Background(), PriorityBackground), "euw1"); err != nil {
+		t.Errorf("other class and region: %v, want no limit", err)
+	}
+
+	s.Close()
+	if err := <-queued; !errors.Is(err, ErrSchedulerClosed) {
+		t.Errorf("queued request after Close: %v, want ErrSchedulerClosed", err)
+	}
+}
+
+func TestSchedulerContextCancel(t *testing.T) {
+	s, _ := newTestScheduler(t, 1, time.Minute, nil)
+
+	if err := s.Acquire(context.Background(), "br1"); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := s.Acquire(ctx, "br1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if depth := s.QueueDepth("br1", PriorityInteractive); depth != 0 {
+		t.Errorf("queue depth after cancel = %d, want 0", depth)
+	}
+}
+
+func TestSchedulerTenantsShareClass(t *testing.T) {
+	s, _ := newTestScheduler(t, 1, 20*time.Millisecond, nil)
+	ctx := context.Background()
+
+	if err := s.Acquire(ctx, "br1"); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	// Tenant a floods the class before b asks once; b must not wait behind
+	// all of a's requests.
+	order := make(chan string, 4)
+	for i := range 3 {
+		acquireAsync(s, WithTenant(ctx, "a"), fmt.Sprintf("a%d", i), order)
+		waitForDepth(t, s, PriorityInteractive, i+1)
+	}
+	acquireAsync(s, WithTenant(ctx, "b"), "b", order)
+
+	var served []string
+	for range 4 {
+		select {
+		case label := <-order:
+			served = append(served, label)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out, served %v", served)
+		}
+	}
+
+	for i, label := range served {
+		if label == "b" && i > 1 {
+			t.Errorf("served %v, want b within the first two", served)
+		}
+	}
+}
+
+func TestSchedulerArrivalsKeepHeadReservation(t *testing.T) {
+	s, limiter := newTestScheduler(t, 1, 100*time.Millisecond, nil)
+	ctx := WithPriority(context.Background(), PriorityBackground)
+
+	if err := s.Acquire(ctx, "br1"); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	head := make(chan error, 1)
+	go func() { head <- s.Acquire(ctx, "br1") }()
+	waitForDepth(t, s, PriorityBackground, 1)
+	before := limiter.reserves.Load()
+
+	// A stream of arrivals of equal or lower priority that give up at once.
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			arrival, cancel := context.WithTimeout(WithPriority(context.Background(), PriorityBulk), time.Millisecond)
+			defer cancel()
+			s.Acquire(arrival, "br1")
+		}()
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-head:
+		if err != nil {
+			t.Fatalf("head Acquire: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("head request starved by arrivals")
+	}
+
+	if got := limiter.reserves.Load() - before; got > 2 {
+		t.Errorf("reservations while arrivals came and went = %d, want the head's slot kept", got)
+	}
+}
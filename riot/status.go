@@ -0,0 +1,27 @@
+package riot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// GetPlatformStatus returns the maintenances and incidents of a platform. It
+// is cheap and needs no player data, which also makes it a good reachability
+// probe.
+func (c *Client) GetPlatformStatus(ctx context.Context, region string) (*PlatformData, error) {
+	endpoint := fmt.Sprintf("%s/tft/status/v1/platform-data",
+		c.getRegionURL("status", region))
+
+	body, err := c.makeRequest(ctx, region, "status.platform-data", "GET", endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("get platform status: %w", err)
+	}
+
+	var platform PlatformData
+	if err := json.Unmarshal(body, &platform); err != nil {
+		return nil, fmt.Errorf("unmarshaling platform status: %w", err)
+	}
+
+	return &platform, nil
+}
@@ -10,7 +10,7 @@ func (c *Client) GetSummonerByPUUID(ctx context.Context, region, puuid string) (
 	endpoint := fmt.Sprintf("%s/tft/summoner/v1/summoners/by-puuid/%s",
 		c.getRegionURL("summoner", region), puuid)
 
-	body, err := c.makeRequest(ctx, "GET", endpoint)
+	body, err := c.makeRequest(ctx, region, "summoner.by-puuid", "GET", endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("get summoner by puuid %s: %w", puuid, err)
 	}
@@ -27,7 +27,7 @@ func (c *Client) GetSummonerByID(ctx context.Context, region, summonerID string)
 	endpoint := fmt.Sprintf("%s/tft/summoner/v1/summoners/%s",
 		c.getRegionURL("summoner", region), summonerID)
 
-	body, err := c.makeRequest(ctx, "GET", endpoint)
+	body, err := c.makeRequest(ctx, region, "summoner.by-id", "GET", endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("get summoner by id %s: %w", summonerID, err)
 	}